@@ -0,0 +1,81 @@
+package serr
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ANSI escape codes used by PrettyColor().
+const (
+	ColorRed   = "\x1b[31m"
+	ColorCyan  = "\x1b[36m"
+	ColorReset = "\x1b[0m"
+)
+
+// NoColor makes PrettyColor() return the same string as Error(). It defaults
+// to true when the NO_COLOR environment variable is set, per no-color.org.
+var NoColor = os.Getenv("NO_COLOR") != ""
+
+// palette holds the ANSI codes used to color each segment of a rendered error.
+// An empty code leaves its segment uncolored.
+type palette struct {
+	message string
+	key     string
+}
+
+// paint wraps s in the ANSI code and a reset, unless code or s is empty.
+func (p palette) paint(code, s string) string {
+	if code == "" || s == "" {
+		return s
+	}
+	return code + s + ColorReset
+}
+
+// PrettyColor renders err as Error() does but for display on a terminal, with
+// its message in red and its arg keys in cyan. Use IsTerminal() to decide
+// whether to call it, e.g.:
+//
+//	if serr.IsTerminal(os.Stderr) { msg = serr.PrettyColor(err) }
+//
+// It returns the same string as Error() when NoColor is true, and "" for a nil
+// err. Render.MaxErrorLen is not applied, as excerpting could cut an escape code
+// in two.
+//
+//goland:noinspection GoUnusedExportedFunction
+func PrettyColor(err error) (s string) {
+	var se *sError
+	if err == nil {
+		goto end
+	}
+	if NoColor {
+		s = err.Error()
+		goto end
+	}
+	se = standIn(err)
+	s = se.paintedMessage(palette{
+		message: ColorRed,
+		key:     ColorCyan,
+	}, se.argsWithDefaults())
+	if url, ok := se.HelpURL(); ok && Render.ShowHelpURL {
+		s += fmt.Sprintf(HelpURLFormat, url)
+	}
+end:
+	return s
+}
+
+// IsTerminal reports whether w is a terminal, i.e. an *os.File for a character
+// device such as os.Stdout when it is not redirected.
+//
+//goland:noinspection GoUnusedExportedFunction
+func IsTerminal(w io.Writer) (is bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		goto end
+	}
+	if info, err := f.Stat(); err == nil {
+		is = info.Mode()&os.ModeCharDevice != 0
+	}
+end:
+	return is
+}
@@ -0,0 +1,57 @@
+package serr_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestPrettyColor(t *testing.T) {
+	defer func(noColor bool) { serr.NoColor = noColor }(serr.NoColor)
+	err := serr.New("not found").Args("id", 1, "table", "users")
+
+	serr.NoColor = false
+	want := serr.ColorRed + "not found" + serr.ColorReset +
+		" [" + serr.ColorCyan + "id" + serr.ColorReset + "=1]" +
+		" [" + serr.ColorCyan + "table" + serr.ColorReset + "='users']"
+	if got := serr.PrettyColor(err); want != got {
+		t.Errorf("Result not equal\n\t\twant=%q\n\t\t got=%q", want, got)
+	}
+
+	plain := err.Error()
+	if strings.Contains(plain, "\x1b[") {
+		t.Errorf("Error() should not contain ANSI codes; got %q", plain)
+	}
+
+	serr.NoColor = true
+	if got := serr.PrettyColor(err); plain != got {
+		t.Errorf("Result not equal\n\t\twant=%q\n\t\t got=%q", plain, got)
+	}
+
+	serr.NoColor = false
+	stdErr := errors.New("disk full")
+	want = serr.ColorRed + "disk full" + serr.ColorReset
+	if got := serr.PrettyColor(stdErr); want != got {
+		t.Errorf("Result not equal\n\t\twant=%q\n\t\t got=%q", want, got)
+	}
+	if got := serr.PrettyColor(nil); got != "" {
+		t.Errorf("PrettyColor(nil) should be empty; got %q", got)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if serr.IsTerminal(f) {
+		t.Errorf("IsTerminal() should be false for a regular file")
+	}
+	if serr.IsTerminal(&strings.Builder{}) {
+		t.Errorf("IsTerminal() should be false for a non-file writer")
+	}
+}
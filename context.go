@@ -0,0 +1,41 @@
+package serr
+
+import (
+	"context"
+	"time"
+)
+
+// Keys of the args WrapContext() attaches.
+const (
+	CtxErrKey    = "ctx_err"
+	DeadlineKey  = "deadline"
+	RemainingKey = "remaining"
+)
+
+// WrapContext wraps err with msg, as Wrap does, and records the state of ctx
+// so a failure caused by cancellation can be told apart from others:
+//
+//   - CtxErrKey is the message of ctx.Err(), e.g. "context canceled" or
+//     "context deadline exceeded", if ctx is done.
+//   - DeadlineKey is ctx's deadline and RemainingKey the time.Duration that
+//     remained until it, negative if it has passed, if ctx has a deadline.
+//
+// WrapContext returns nil if err is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WrapContext(ctx context.Context, err error, msg string) SError {
+	var args []any
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		args = append(args, CtxErrKey, ctxErr.Error())
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		args = append(args,
+			DeadlineKey, deadline,
+			RemainingKey, time.Until(deadline),
+		)
+	}
+	return Wrap(err, msg, args...)
+}
@@ -0,0 +1,66 @@
+package serr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestWrapContext(t *testing.T) {
+	cause := errors.New("request failed")
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := serr.WrapContext(ctx, cause, "fetching")
+		want := "fetching [ctx_err='context canceled']"
+		if got := err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		if _, found := err.Attr(serr.DeadlineKey); found {
+			t.Errorf("A context without a deadline should not record one")
+		}
+		if !errors.Is(err, cause) {
+			t.Errorf("errors.Is() should find the cause")
+		}
+	})
+
+	t.Run("Deadline exceeded", func(t *testing.T) {
+		deadline := time.Now().Add(-time.Second)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+		err := serr.WrapContext(ctx, cause, "fetching")
+		attr, _ := err.Attr(serr.CtxErrKey)
+		if want, got := context.DeadlineExceeded.Error(), attr.Value.String(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		attr, _ = err.Attr(serr.DeadlineKey)
+		if got := attr.Value.Time(); !got.Equal(deadline) {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", deadline, got)
+		}
+		attr, _ = err.Attr(serr.RemainingKey)
+		if got := attr.Value.Duration(); got >= 0 {
+			t.Errorf("Remaining should be negative once the deadline has passed; got %s", got)
+		}
+	})
+
+	t.Run("Deadline pending", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		err := serr.WrapContext(ctx, cause, "fetching")
+		if _, found := err.Attr(serr.CtxErrKey); found {
+			t.Errorf("A context that is not done should not record an error")
+		}
+		attr, _ := err.Attr(serr.RemainingKey)
+		if got := attr.Value.Duration(); got <= 0 || got > time.Hour {
+			t.Errorf("Remaining should be within the timeout; got %s", got)
+		}
+	})
+
+	if err := serr.WrapContext(context.Background(), nil, "fetching"); err != nil {
+		t.Errorf("WrapContext() of a nil error should be nil; got %v", err)
+	}
+}
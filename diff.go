@@ -0,0 +1,448 @@
+package serr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+const LineNumberFormat = "%d: %s"
+
+// DiffLineWidth is the width in runes beyond which DiffLines() excerpts lines.
+var DiffLineWidth = 100
+
+// DiffLines compares s1 and s2 line by line and returns the lines of each that
+// differ from the line at the same position in the other, formatted with their
+// 1-based line number using LineNumberFormat. Lines present in only one input
+// are returned for that input only. Lines longer than DiffLineWidth are
+// excerpted.
+//
+//goland:noinspection GoUnusedExportedFunction
+func DiffLines(s1, s2 string) (lines1, lines2 []string) {
+	split1 := strings.Split(s1, "\n")
+	split2 := strings.Split(s2, "\n")
+	for i := 0; i < max(len(split1), len(split2)); i++ {
+		switch {
+		case i >= len(split1):
+			lines2 = append(lines2, diffLine(i, split2[i]))
+		case i >= len(split2):
+			lines1 = append(lines1, diffLine(i, split1[i]))
+		case split1[i] != split2[i]:
+			lines1 = append(lines1, diffLine(i, split1[i]))
+			lines2 = append(lines2, diffLine(i, split2[i]))
+		}
+	}
+	return lines1, lines2
+}
+
+func diffLine(index int, line string) string {
+	return fmt.Sprintf(LineNumberFormat, index+1, Excerpt(line, DiffLineWidth))
+}
+
+// DiffReaders compares the runes read from r1 and r2 without holding either in
+// memory and returns the region of each starting at the first rune where they
+// differ, along with the offset of that rune. Each region is at most n runes;
+// one that was cut short ends with EllipsisRune. If the readers produce the
+// same runes both regions are empty and start is the number of runes read.
+//
+//goland:noinspection GoUnusedExportedFunction
+func DiffReaders(r1, r2 io.Reader, n int) (_, _ string, start int, err error) {
+	var s1, s2 string
+	var ch1, ch2 rune
+	var err1, err2 error
+	br1 := bufio.NewReader(r1)
+	br2 := bufio.NewReader(r2)
+
+	// Scan from the beginning and look for the first runes that are not the same.
+	for {
+		ch1, _, err1 = br1.ReadRune()
+		ch2, _, err2 = br2.ReadRune()
+		if err1 != nil || err2 != nil || ch1 != ch2 {
+			break
+		}
+		start++
+	}
+	err = readErr(err1, err2)
+	if err != nil {
+		goto end
+	}
+	if err1 == io.EOF && err2 == io.EOF {
+		goto end
+	}
+
+	// Put back the differing runes and read the region that follows from each.
+	if err1 == nil {
+		_ = br1.UnreadRune()
+	}
+	if err2 == nil {
+		_ = br2.UnreadRune()
+	}
+	s1, err = readRegion(br1, n)
+	if err != nil {
+		goto end
+	}
+	s2, err = readRegion(br2, n)
+end:
+	return s1, s2, start, err
+}
+
+// readErr returns whichever of err1 and err2 is an error other than io.EOF.
+func readErr(err1, err2 error) (err error) {
+	switch {
+	case err1 != nil && err1 != io.EOF:
+		err = err1
+	case err2 != nil && err2 != io.EOF:
+		err = err2
+	}
+	return err
+}
+
+// readRegion reads up to n runes from br, replacing the last with EllipsisRune
+// if there are more to read. A negative n is treated as zero.
+func readRegion(br *bufio.Reader, n int) (s string, err error) {
+	var r rune
+	n = max(n, 0)
+	region := make([]rune, 0, n+1)
+	for len(region) <= n {
+		r, _, err = br.ReadRune()
+		if err != nil {
+			break
+		}
+		region = append(region, r)
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	if len(region) > n && n > 0 {
+		region = append(region[:n-1], []rune(EllipsisRune)...)
+	}
+	s = string(region[:min(len(region), n)])
+	return s, err
+}
+
+// DiffIgnoreSpace works like Diff but skips whitespace in both strings while
+// scanning, so strings that differ only in whitespace are reported as equal.
+// The returned excerpts are taken from the original strings, whitespace
+// included, and start and end count the runes of s1 before and after its
+// differing region.
+//
+//goland:noinspection GoUnusedExportedFunction
+func DiffIgnoreSpace(s1, s2 string, n int) (_, _ string, start, end int) {
+	r1 := []rune(s1)
+	r2 := []rune(s2)
+	i, j := 0, 0
+	k, l := len(r1), len(r2)
+
+	// Scan from the beginning, skipping whitespace, for the first runes that are
+	// not the same.
+	for {
+		i = skipSpace(r1, i, len(r1))
+		j = skipSpace(r2, j, len(r2))
+		if i == len(r1) || j == len(r2) || r1[i] != r2[j] {
+			break
+		}
+		i++
+		j++
+	}
+	if i == len(r1) && j == len(r2) {
+		s1 = ""
+		s2 = ""
+		start = len(r1)
+		goto end
+	}
+
+	// Now scan back from the end, skipping whitespace, without passing the
+	// runes already found to differ.
+	for {
+		k = skipSpaceBack(r1, i, k)
+		l = skipSpaceBack(r2, j, l)
+		if k == i || l == j || r1[k-1] != r2[l-1] {
+			break
+		}
+		k--
+		l--
+	}
+	s1 = Excerpt(string(r1[i:k]), n)
+	s2 = Excerpt(string(r2[j:l]), n)
+	start = i
+	end = len(r1) - k
+end:
+	return s1, s2, start, end
+}
+
+// skipSpace returns the index of the first non-space rune in runes[from:to],
+// or to if there is none.
+func skipSpace(runes []rune, from, to int) int {
+	for from < to && unicode.IsSpace(runes[from]) {
+		from++
+	}
+	return from
+}
+
+// skipSpaceBack returns the index just past the last non-space rune in
+// runes[from:to], or from if there is none.
+func skipSpaceBack(runes []rune, from, to int) int {
+	for to > from && unicode.IsSpace(runes[to-1]) {
+		to--
+	}
+	return to
+}
+
+// Spans holds the lengths in runes of the common prefix and common suffix of
+// two strings, as returned by DiffSpans().
+type Spans struct {
+	PrefixRunes int
+	SuffixRunes int
+}
+
+// DiffSpans returns the lengths in runes of the longest common prefix of s1 and
+// s2 and of the longest common suffix of what remains after it, so that the
+// differing middle of each is []rune(s)[PrefixRunes:len(runes)-SuffixRunes].
+// The prefix and suffix never overlap. Unlike Diff() nothing is excerpted and
+// no byte counts are involved.
+//
+//goland:noinspection GoUnusedExportedFunction
+func DiffSpans(s1, s2 string) (spans Spans) {
+	r1 := []rune(s1)
+	r2 := []rune(s2)
+	n := min(len(r1), len(r2))
+	for spans.PrefixRunes < n && r1[spans.PrefixRunes] == r2[spans.PrefixRunes] {
+		spans.PrefixRunes++
+	}
+	n -= spans.PrefixRunes
+	for spans.SuffixRunes < n &&
+		r1[len(r1)-1-spans.SuffixRunes] == r2[len(r2)-1-spans.SuffixRunes] {
+		spans.SuffixRunes++
+	}
+	return spans
+}
+
+// EditKind identifies what an EditOp does.
+type EditKind int
+
+const (
+	// EditKeep is text present in both strings.
+	EditKeep EditKind = iota
+	// EditDelete is text present only in the first string.
+	EditDelete
+	// EditInsert is text present only in the second string.
+	EditInsert
+)
+
+func (k EditKind) String() (s string) {
+	switch k {
+	case EditKeep:
+		s = "keep"
+	case EditDelete:
+		s = "delete"
+	case EditInsert:
+		s = "insert"
+	default:
+		s = fmt.Sprintf("EditKind(%d)", int(k))
+	}
+	return s
+}
+
+// EditOp is one step of the edit script returned by DiffOps(): a run of runes
+// to keep, delete or insert.
+type EditOp struct {
+	Kind EditKind
+	Text string
+}
+
+// DiffOps returns the shortest rune-level edit script that turns s1 into s2,
+// based on their longest common subsequence, as runs of kept, deleted and
+// inserted text in order. Where runes are replaced the deletion precedes the
+// insertion. Unlike Diff() it describes every region where the strings differ,
+// for rendering an inline diff.
+//
+// It takes time and memory proportional to the product of the lengths of the
+// strings once their common prefix and suffix are removed.
+//
+//goland:noinspection GoUnusedExportedFunction
+func DiffOps(s1, s2 string) (ops []EditOp) {
+	r1 := []rune(s1)
+	r2 := []rune(s2)
+	for _, step := range editScript(r1, r2) {
+		switch step.kind {
+		case EditInsert:
+			ops = appendEditOp(ops, step.kind, r2[step.j])
+		default:
+			ops = appendEditOp(ops, step.kind, r1[step.i])
+		}
+	}
+	return ops
+}
+
+// DiffUnified compares s1 and s2 line by line and returns their differences in
+// the style of a unified diff: hunks headed by "@@ -start,count +start,count @@"
+// whose lines are prefixed with "-" if only in s1, "+" if only in s2, or " " if
+// unchanged context. Each hunk has up to context unchanged lines before and
+// after its changes, and hunks whose context would overlap are merged. A final
+// newline ends the last line rather than adding an empty one, so it is not
+// reported as a difference. No file headers are included. DiffUnified returns
+// "" if s1 and s2 are equal.
+//
+//goland:noinspection GoUnusedExportedFunction
+func DiffUnified(s1, s2 string, context int) string {
+	var changes []int
+	var hunks []string
+	lines1 := unifiedLines(s1)
+	lines2 := unifiedLines(s2)
+	steps := editScript(lines1, lines2)
+	for k, step := range steps {
+		if step.kind != EditKeep {
+			changes = append(changes, k)
+		}
+	}
+	context = max(context, 0)
+	for c := 0; c < len(changes); {
+		// Extend the hunk over every change within twice the context of the last.
+		last := c
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*context+1 {
+			last++
+		}
+		lo := max(changes[c]-context, 0)
+		hi := min(changes[last]+context+1, len(steps))
+		hunks = append(hunks, unifiedHunk(steps[lo:hi], lines1, lines2))
+		c = last + 1
+	}
+	return strings.Join(hunks, "\n")
+}
+
+// unifiedLines splits s into lines. A final newline ends the last line rather
+// than starting an empty one, as in a text file.
+func unifiedLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// unifiedHunk renders the steps of one hunk of DiffUnified().
+func unifiedHunk(steps []editStep, lines1, lines2 []string) string {
+	var count1, count2 int
+	body := make([]string, 0, len(steps))
+	for _, step := range steps {
+		switch step.kind {
+		case EditKeep:
+			body = append(body, " "+lines1[step.i])
+			count1++
+			count2++
+		case EditDelete:
+			body = append(body, "-"+lines1[step.i])
+			count1++
+		case EditInsert:
+			body = append(body, "+"+lines2[step.j])
+			count2++
+		}
+	}
+	header := fmt.Sprintf("@@ -%s +%s @@",
+		hunkRange(steps[0].i, count1),
+		hunkRange(steps[0].j, count2),
+	)
+	return header + "\n" + strings.Join(body, "\n")
+}
+
+// hunkRange renders the 0-based start and line count of one side of a hunk as
+// a unified diff does: "start,count" with a 1-based start, just "start" when
+// the count is 1, and the line before the hunk when the count is 0.
+func hunkRange(start, count int) (s string) {
+	switch count {
+	case 0:
+		s = fmt.Sprintf("%d,0", start)
+	case 1:
+		s = fmt.Sprintf("%d", start+1)
+	default:
+		s = fmt.Sprintf("%d,%d", start+1, count)
+	}
+	return s
+}
+
+// editStep is one element of an edit script: a[i] kept as b[j], a[i] deleted,
+// or b[j] inserted. For a deletion j is the index in b it precedes, and for an
+// insertion i is the index in a it precedes.
+type editStep struct {
+	kind EditKind
+	i, j int
+}
+
+// editScript returns the shortest edit script turning a into b, one step per
+// element, based on their longest common subsequence. Where elements are
+// replaced the deletion precedes the insertion. Only the elements between the
+// common prefix and suffix of a and b are compared pairwise.
+func editScript[T comparable](a, b []T) (steps []editStep) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of midA[i:] and
+	// midB[j:].
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	steps = make([]editStep, 0, len(a)+len(b)-prefix-suffix)
+	for k := 0; k < prefix; k++ {
+		steps = append(steps, editStep{kind: EditKeep, i: k, j: k})
+	}
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			steps = append(steps, editStep{kind: EditKeep, i: prefix + i, j: prefix + j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			steps = append(steps, editStep{kind: EditDelete, i: prefix + i, j: prefix + j})
+			i++
+		default:
+			steps = append(steps, editStep{kind: EditInsert, i: prefix + i, j: prefix + j})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		steps = append(steps, editStep{kind: EditDelete, i: prefix + i, j: prefix + j})
+	}
+	for ; j < len(midB); j++ {
+		steps = append(steps, editStep{kind: EditInsert, i: prefix + i, j: prefix + j})
+	}
+	for k := suffix; k > 0; k-- {
+		steps = append(steps, editStep{kind: EditKeep, i: len(a) - k, j: len(b) - k})
+	}
+	return steps
+}
+
+// appendEditOp appends runes to the last op if it is of the same kind, or as a
+// new op otherwise.
+func appendEditOp(ops []EditOp, kind EditKind, runes ...rune) []EditOp {
+	if len(runes) == 0 {
+		return ops
+	}
+	if n := len(ops); n > 0 && ops[n-1].Kind == kind {
+		ops[n-1].Text += string(runes)
+		return ops
+	}
+	return append(ops, EditOp{Kind: kind, Text: string(runes)})
+}
@@ -0,0 +1,394 @@
+package serr_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestDiffLines(t *testing.T) {
+	var tests = []struct {
+		name             string
+		source1, source2 string
+		want1, want2     []string
+	}{
+		{
+			name:    "Identical",
+			source1: "a\nb\nc",
+			source2: "a\nb\nc",
+		},
+		{
+			name:    "One line differs",
+			source1: "host: localhost\nport: 80\ndebug: false",
+			source2: "host: localhost\nport: 8080\ndebug: false",
+			want1:   []string{"2: port: 80"},
+			want2:   []string{"2: port: 8080"},
+		},
+		{
+			name:    "Several lines differ",
+			source1: "a\nb\nc\nd",
+			source2: "a\nB\nc\nD",
+			want1:   []string{"2: b", "4: d"},
+			want2:   []string{"2: B", "4: D"},
+		},
+		{
+			name:    "Second has extra lines",
+			source1: "a\nb",
+			source2: "a\nb\nc\nd",
+			want2:   []string{"3: c", "4: d"},
+		},
+		{
+			name:    "Long line excerpted",
+			source1: "a\n" + Xs[:150] + "X",
+			source2: "a\n" + Xs[:150] + "Y",
+			want1:   []string{fmt.Sprintf("2: %s%s%sX", Xs[:50], serr.EllipsisRune, Xs[:48])},
+			want2:   []string{fmt.Sprintf("2: %s%s%sY", Xs[:50], serr.EllipsisRune, Xs[:48])},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got1, got2 := serr.DiffLines(test.source1, test.source2)
+			if !slices.Equal(test.want1, got1) {
+				t.Errorf("Lines mismatch [source 1]\n\twant=%q\n\t got=%q", test.want1, got1)
+			}
+			if !slices.Equal(test.want2, got2) {
+				t.Errorf("Lines mismatch [source 2]\n\twant=%q\n\t got=%q", test.want2, got2)
+			}
+		})
+	}
+}
+
+func TestDiffReaders(t *testing.T) {
+	const size = 1 << 20
+	big := strings.Repeat("-", size)
+	var tests = []struct {
+		name             string
+		source1, source2 string
+		want1, want2     string
+		wantStart        int
+		n                int
+	}{
+		{
+			name:      "Identical",
+			source1:   big,
+			source2:   big,
+			wantStart: size,
+			n:         10,
+		},
+		{
+			name:      "Large, differ in middle",
+			source1:   big + "ABC" + big,
+			source2:   big + "XYZ" + big,
+			want1:     fmt.Sprintf("ABC--%s", serr.EllipsisRune),
+			want2:     fmt.Sprintf("XYZ--%s", serr.EllipsisRune),
+			wantStart: size,
+			n:         6,
+		},
+		{
+			name:      "Short region at end",
+			source1:   big + "AB",
+			source2:   big + "XY",
+			want1:     "AB",
+			want2:     "XY",
+			wantStart: size,
+			n:         6,
+		},
+		{
+			name:      "One is a prefix of the other",
+			source1:   big,
+			source2:   big + "tail",
+			want1:     "",
+			want2:     "tail",
+			wantStart: size,
+			n:         6,
+		},
+		{
+			name:      "Multibyte runes",
+			source1:   "日本語のテキスト",
+			source2:   "日本語のデータ",
+			want1:     "テキスト",
+			want2:     "データ",
+			wantStart: 4,
+			n:         6,
+		},
+		{
+			name:      "Negative width",
+			source1:   "aXb",
+			source2:   "aYb",
+			wantStart: 1,
+			n:         -2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got1, got2, start, err := serr.DiffReaders(
+				strings.NewReader(test.source1),
+				strings.NewReader(test.source2),
+				test.n,
+			)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if start != test.wantStart {
+				t.Errorf("Start mismatch\n\twant=%d\n\t got=%d", test.wantStart, start)
+			}
+			if got1 != test.want1 || got2 != test.want2 {
+				t.Errorf("Region mismatch\n\twant=%q, %q\n\t got=%q, %q", test.want1, test.want2, got1, got2)
+			}
+		})
+	}
+	t.Run("Read error", func(t *testing.T) {
+		errRead := errors.New("read failed")
+		_, _, _, err := serr.DiffReaders(iotest.ErrReader(errRead), strings.NewReader("x"), 5)
+		if !errors.Is(err, errRead) {
+			t.Errorf("Error mismatch\n\twant=%v\n\t got=%v", errRead, err)
+		}
+	})
+}
+
+func TestDiffIgnoreSpace(t *testing.T) {
+	var tests = []struct {
+		name             string
+		source1, source2 string
+		want1, want2     string
+		n                int
+	}{
+		{
+			name:    "Only spacing differs",
+			source1: "SELECT  id,\n\tname FROM users",
+			source2: "SELECT id, name\nFROM   users  ",
+			n:       25,
+		},
+		{
+			name:    "Content differs in middle",
+			source1: "foo  bar baz  qux",
+			source2: "foo bar  zap qux",
+			want1:   "baz",
+			want2:   "zap",
+			n:       25,
+		},
+		{
+			name:    "Excerpt keeps original whitespace",
+			source1: "start A  B end",
+			source2: "start X Y end",
+			want1:   "A  B",
+			want2:   "X Y",
+			n:       25,
+		},
+		{
+			name:    "Extra trailing content",
+			source1: "a b c",
+			source2: "a  b  c d",
+			want1:   "",
+			want2:   "d",
+			n:       25,
+		},
+		{
+			name:    "Long difference excerpted",
+			source1: "x ABCDEFGHI y",
+			source2: "x  QRSTUVXYZ y",
+			want1:   fmt.Sprintf("AB%sHI", serr.EllipsisRune),
+			want2:   fmt.Sprintf("QR%sYZ", serr.EllipsisRune),
+			n:       5,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got1, got2, _, _ := serr.DiffIgnoreSpace(test.source1, test.source2, test.n)
+			verifyDiffResult(t, 1, test.source1, test.want1, got1)
+			verifyDiffResult(t, 2, test.source2, test.want2, got2)
+		})
+	}
+}
+
+func TestDiffOps(t *testing.T) {
+	keep := func(s string) serr.EditOp { return serr.EditOp{Kind: serr.EditKeep, Text: s} }
+	del := func(s string) serr.EditOp { return serr.EditOp{Kind: serr.EditDelete, Text: s} }
+	ins := func(s string) serr.EditOp { return serr.EditOp{Kind: serr.EditInsert, Text: s} }
+	var tests = []struct {
+		name string
+		s1   string
+		s2   string
+		want []serr.EditOp
+	}{
+		{name: "Equal", s1: "same", s2: "same", want: []serr.EditOp{keep("same")}},
+		{name: "Both empty", s1: "", s2: "", want: nil},
+		{name: "Insert only", s1: "", s2: "new", want: []serr.EditOp{ins("new")}},
+		{name: "Delete only", s1: "old", s2: "", want: []serr.EditOp{del("old")}},
+		{
+			name: "Multiple regions",
+			s1:   "kitten",
+			s2:   "sitting",
+			want: []serr.EditOp{del("k"), ins("s"), keep("itt"), del("e"), ins("i"), keep("n"), ins("g")},
+		},
+		{
+			name: "Words in two places",
+			s1:   "the red fox ran",
+			s2:   "the tan fox sat",
+			want: []serr.EditOp{keep("the "), del("red"), ins("tan"), keep(" fox "), del("r"), ins("s"), keep("a"), del("n"), ins("t")},
+		},
+		{
+			name: "Multibyte runes",
+			s1:   "日本語",
+			s2:   "日英語",
+			want: []serr.EditOp{keep("日"), del("本"), ins("英"), keep("語")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := serr.DiffOps(test.s1, test.s2)
+			if !slices.Equal(test.want, got) {
+				t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", test.want, got)
+			}
+			var from, to strings.Builder
+			for _, op := range got {
+				if op.Kind != serr.EditInsert {
+					from.WriteString(op.Text)
+				}
+				if op.Kind != serr.EditDelete {
+					to.WriteString(op.Text)
+				}
+			}
+			if from.String() != test.s1 || to.String() != test.s2 {
+				t.Errorf("Edit script does not reproduce its inputs\n\t\twant=%s, %s\n\t\t got=%s, %s",
+					test.s1, test.s2, from.String(), to.String())
+			}
+		})
+	}
+}
+
+func TestDiffSpans(t *testing.T) {
+	var tests = []struct {
+		name    string
+		s1      string
+		s2      string
+		want    serr.Spans
+		middle1 string
+		middle2 string
+	}{
+		{name: "Equal", s1: "same", s2: "same", want: serr.Spans{PrefixRunes: 4}},
+		{name: "ASCII", s1: "abcXdef", s2: "abcYYdef", want: serr.Spans{PrefixRunes: 3, SuffixRunes: 3}, middle1: "X", middle2: "YY"},
+		{name: "Multibyte", s1: "日本語のテキスト", s2: "日本語の文章テキスト", want: serr.Spans{PrefixRunes: 4, SuffixRunes: 4}, middle2: "文章"},
+		{name: "Multibyte differing runes", s1: "café—ok", s2: "cafè—ok", want: serr.Spans{PrefixRunes: 3, SuffixRunes: 3}, middle1: "é", middle2: "è"},
+		{name: "No overlap", s1: "aaa", s2: "aa", want: serr.Spans{PrefixRunes: 2}, middle1: "a"},
+		{name: "Empty", s1: "", s2: "日本", want: serr.Spans{}, middle2: "日本"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := serr.DiffSpans(test.s1, test.s2)
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%+v\n\t\t got=%+v", test.want, got)
+			}
+			r1, r2 := []rune(test.s1), []rune(test.s2)
+			middle1 := string(r1[got.PrefixRunes : len(r1)-got.SuffixRunes])
+			middle2 := string(r2[got.PrefixRunes : len(r2)-got.SuffixRunes])
+			if test.middle1 != middle1 || test.middle2 != middle2 {
+				t.Errorf("Middles not equal\n\t\twant=%q, %q\n\t\t got=%q, %q", test.middle1, test.middle2, middle1, middle2)
+			}
+		})
+	}
+}
+
+func TestDiffUnified(t *testing.T) {
+	before := strings.Join([]string{
+		"host=localhost",
+		"port=5432",
+		"user=admin",
+		"pool=10",
+		"timeout=30",
+		"retries=3",
+		"log=info",
+		"cache=on",
+	}, "\n")
+	after := strings.Join([]string{
+		"host=localhost",
+		"port=6543",
+		"user=admin",
+		"pool=10",
+		"timeout=30",
+		"retries=3",
+		"log=info",
+		"cache=off",
+		"tls=on",
+	}, "\n")
+	var tests = []struct {
+		name    string
+		s1      string
+		s2      string
+		context int
+		want    string
+	}{
+		{name: "Equal", s1: before, s2: before, context: 3, want: ""},
+		{
+			name:    "Trailing newline",
+			s1:      "a\nb\n",
+			s2:      "a\nc\n",
+			context: 1,
+			want:    "@@ -1,2 +1,2 @@\n a\n-b\n+c",
+		},
+		{
+			name:    "Added to empty",
+			s1:      "",
+			s2:      "a\n",
+			context: 1,
+			want:    "@@ -0,0 +1 @@\n+a",
+		},
+		{
+			name:    "Two hunks",
+			s1:      before,
+			s2:      after,
+			context: 1,
+			want: strings.Join([]string{
+				"@@ -1,3 +1,3 @@",
+				" host=localhost",
+				"-port=5432",
+				"+port=6543",
+				" user=admin",
+				"@@ -7,2 +7,3 @@",
+				" log=info",
+				"-cache=on",
+				"+cache=off",
+				"+tls=on",
+			}, "\n"),
+		},
+		{
+			name:    "Merged hunk",
+			s1:      before,
+			s2:      after,
+			context: 3,
+			want: strings.Join([]string{
+				"@@ -1,8 +1,9 @@",
+				" host=localhost",
+				"-port=5432",
+				"+port=6543",
+				" user=admin",
+				" pool=10",
+				" timeout=30",
+				" retries=3",
+				" log=info",
+				"-cache=on",
+				"+cache=off",
+				"+tls=on",
+			}, "\n"),
+		},
+		{
+			name:    "No context",
+			s1:      "a\nb\nc",
+			s2:      "a\nc",
+			context: 0,
+			want:    "@@ -2 +1,0 @@\n-b",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serr.DiffUnified(test.s1, test.s2, test.context); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
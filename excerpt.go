@@ -0,0 +1,268 @@
+package serr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// wideRanges are the rune ranges whose East Asian Width is Wide or Fullwidth,
+// i.e. that occupy two columns in a terminal.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK Radicals through CJK Symbols and Punctuation
+	{0x3041, 0x33FF},   // Hiragana through CJK Compatibility
+	{0x3400, 0x4DBF},   // CJK Unified Ideographs Extension A
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0xA000, 0xA4CF},   // Yi Syllables and Radicals
+	{0xAC00, 0xD7A3},   // Hangul Syllables
+	{0xF900, 0xFAFF},   // CJK Compatibility Ideographs
+	{0xFE30, 0xFE4F},   // CJK Compatibility Forms
+	{0xFF00, 0xFF60},   // Fullwidth Forms
+	{0xFFE0, 0xFFE6},   // Fullwidth Signs
+	{0x1F300, 0x1F64F}, // Miscellaneous Symbols and Pictographs, Emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental Symbols and Pictographs
+	{0x20000, 0x2FFFD}, // CJK Unified Ideographs Extension B and beyond
+	{0x30000, 0x3FFFD}, // CJK Unified Ideographs Extension G and beyond
+}
+
+// RuneWidth returns the number of terminal columns r occupies: 2 for runes
+// with an East Asian Width of Wide or Fullwidth, otherwise 1.
+func RuneWidth(r rune) (width int) {
+	width = 1
+	for _, wide := range wideRanges {
+		if r < wide[0] {
+			break
+		}
+		if r <= wide[1] {
+			width = 2
+			break
+		}
+	}
+	return width
+}
+
+// DisplayWidth returns the number of terminal columns s occupies, per
+// RuneWidth().
+func DisplayWidth(s string) (width int) {
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// ExcerptDisplay works like Excerpt but treats width as a budget of terminal
+// columns rather than runes, so excerpts of CJK and other wide text fit a
+// fixed-width column. The result never exceeds width columns, though it may be
+// narrower when a wide rune would straddle the boundary. As with Excerpt the
+// ellipsis is always shown when s is cut, even if width is less than 1.
+//
+//goland:noinspection GoUnusedExportedFunction
+func ExcerptDisplay(s string, width int) string {
+	var prefix, suffix int
+
+	if DisplayWidth(s) <= max(width, 0) {
+		goto end
+	}
+	if width < 1 {
+		s = EllipsisRune
+		goto end
+	}
+
+	// Split the width as Excerpt() does, reserving one column for the ellipsis.
+	prefix = width / 2
+	suffix = width - prefix - RuneWidth([]rune(EllipsisRune)[0])
+	s = fmt.Sprintf(ExcerptFormat,
+		prefixColumns(s, prefix),
+		EllipsisRune,
+		suffixColumns(s, suffix),
+	)
+end:
+	return s
+}
+
+// prefixColumns returns the longest prefix of input at most n columns wide.
+func prefixColumns(input string, n int) string {
+	var width int
+	result := make([]rune, 0)
+	for _, r := range input {
+		width += RuneWidth(r)
+		if width > n {
+			break
+		}
+		result = append(result, r)
+	}
+	return string(result)
+}
+
+// suffixColumns returns the longest suffix of input at most n columns wide.
+func suffixColumns(input string, n int) string {
+	var result []rune
+	b := []byte(input)
+	width := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeLastRune(b)
+		width += RuneWidth(r)
+		if width > n {
+			break
+		}
+		result = append(result, r)
+		b = b[:len(b)-size]
+	}
+	slices.Reverse(result)
+	return string(result)
+}
+
+// ExcerptParts keeps exactly the first head runes and the last tail runes of s
+// with EllipsisRune between them. If head+tail is at least the number of runes
+// in s, s is returned unchanged.
+//
+//goland:noinspection GoUnusedExportedFunction
+func ExcerptParts(s string, head, tail int) string {
+	head = max(head, 0)
+	tail = max(tail, 0)
+	if head+tail >= utf8.RuneCountInString(s) {
+		return s
+	}
+	return fmt.Sprintf(ExcerptFormat,
+		prefixRunes(s, head),
+		EllipsisRune,
+		suffixRunes(s, tail),
+	)
+}
+
+// ExcerptReader returns the same excerpt Excerpt() would for the content of r.
+// If r is an io.ReadSeeker only the runes needed for the head are read from the
+// start, and only the bytes needed for the tail are read after seeking to the
+// end. Otherwise r is read fully.
+//
+//goland:noinspection GoUnusedExportedFunction
+func ExcerptReader(r io.Reader, width int) (s string, err error) {
+	var b []byte
+	var head []rune
+	var tail string
+	var prefix, suffix int
+
+	seeker, ok := r.(io.ReadSeeker)
+	if !ok {
+		b, err = io.ReadAll(r)
+		s = Excerpt(string(b), width)
+		goto end
+	}
+
+	// Read one rune more than fits so we know whether excerpting is needed.
+	head, err = readRunes(bufio.NewReader(seeker), max(width, 0)+1)
+	if err != nil || len(head) <= width {
+		s = string(head)
+		goto end
+	}
+	if width < 1 {
+		s = Excerpt(string(head), width)
+		goto end
+	}
+
+	// Split the width as Excerpt() does.
+	prefix = width / 2
+	suffix = prefix
+	if width%2 == 0 {
+		suffix--
+	}
+	tail, err = readTailRunes(seeker, suffix)
+	if err != nil {
+		goto end
+	}
+	s = fmt.Sprintf(ExcerptFormat,
+		string(head[:prefix]),
+		EllipsisRune,
+		tail,
+	)
+end:
+	return s, err
+}
+
+// readRunes reads up to n runes from br, stopping early at io.EOF.
+func readRunes(br *bufio.Reader, n int) (runes []rune, err error) {
+	var r rune
+	runes = make([]rune, 0, max(n, 0))
+	for len(runes) < n {
+		r, _, err = br.ReadRune()
+		if err != nil {
+			break
+		}
+		runes = append(runes, r)
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return runes, err
+}
+
+// readTailRunes returns the last n runes of rs, reading no more than the
+// utf8.UTFMax bytes per rune that n runes could occupy.
+func readTailRunes(rs io.ReadSeeker, n int) (s string, err error) {
+	var size int64
+	var b []byte
+	size, err = rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		goto end
+	}
+	_, err = rs.Seek(-min(size, int64(n*utf8.UTFMax)), io.SeekEnd)
+	if err != nil {
+		goto end
+	}
+	b, err = io.ReadAll(rs)
+	if err != nil {
+		goto end
+	}
+	s = suffixRunes(string(b), n)
+end:
+	return s, err
+}
+
+// ExcerptAround works like Excerpt but keeps the first occurrence of substr
+// visible, centering the excerpt on it with an ellipsis at either end that was
+// cut. If substr is too long to fit, the excerpt starts at substr. If substr
+// is not found in s, or width leaves no room for two ellipses, ExcerptAround
+// falls back to Excerpt.
+//
+//goland:noinspection GoUnusedExportedFunction
+func ExcerptAround(s, substr string, width int) (result string) {
+	var runes []rune
+	var start, size, content, lo, hi int
+
+	idx := strings.Index(s, substr)
+	if idx < 0 || substr == "" || width < 3 {
+		result = Excerpt(s, width)
+		goto end
+	}
+	runes = []rune(s)
+	if len(runes) <= width {
+		result = s
+		goto end
+	}
+	start = utf8.RuneCountInString(s[:idx])
+	size = utf8.RuneCountInString(substr)
+
+	// Assume both ends are cut, leaving room for an ellipsis at each.
+	content = width - 2
+	lo = min(start-(content-size)/2, start)
+	hi = lo + content
+	switch {
+	case lo <= 0:
+		lo, hi = 0, width-1
+	case hi >= len(runes):
+		lo, hi = len(runes)-(width-1), len(runes)
+	}
+	if lo > 0 {
+		result = EllipsisRune
+	}
+	result += string(runes[lo:hi])
+	if hi < len(runes) {
+		result += EllipsisRune
+	}
+end:
+	return result
+}
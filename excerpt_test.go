@@ -0,0 +1,235 @@
+package serr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	var tests = []struct {
+		source string
+		want   int
+	}{
+		{source: "", want: 0},
+		{source: "ABC", want: 3},
+		{source: "日本語", want: 6},
+		{source: "한국어abc", want: 9},
+		{source: "ＡＢ", want: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			if got := serr.DisplayWidth(test.source); got != test.want {
+				t.Errorf("DisplayWidth() mismatch\n\t\twant=%d\n\t\t got=%d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestExcerptDisplay(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		width  int
+		want   string
+	}{
+		{
+			name:   "Fits",
+			source: "日本語",
+			width:  6,
+			want:   "日本語",
+		},
+		{
+			name:   "CJK odd width",
+			source: "日本語のテキストです",
+			width:  9,
+			want:   fmt.Sprintf("日本%sです", serr.EllipsisRune),
+		},
+		{
+			name:   "CJK even width leaves a gap",
+			source: "日本語のテキストです",
+			width:  10,
+			want:   fmt.Sprintf("日本%sです", serr.EllipsisRune),
+		},
+		{
+			name:   "Mixed narrow and wide",
+			source: "ab日本語のテキストcd",
+			width:  8,
+			want:   fmt.Sprintf("ab日%scd", serr.EllipsisRune),
+		},
+		{
+			name:   "ASCII matches Excerpt",
+			source: "ABCDEFGHIJ",
+			width:  7,
+			want:   serr.Excerpt("ABCDEFGHIJ", 7),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := serr.ExcerptDisplay(test.source, test.width)
+			if gotWidth := serr.DisplayWidth(got); gotWidth > test.width {
+				t.Errorf("Result wider than allowed\n\t\twant<=%d\n\t\t  got=%d", test.width, gotWidth)
+			}
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestExcerptParts(t *testing.T) {
+	var tests = []struct {
+		name       string
+		source     string
+		head, tail int
+		want       string
+	}{
+		{
+			name:   "Uneven head and tail",
+			source: "ABCDEFGHIJKLMNOPQRST",
+			head:   10,
+			tail:   5,
+			want:   fmt.Sprintf("ABCDEFGHIJ%sPQRST", serr.EllipsisRune),
+		},
+		{
+			name:   "Head only",
+			source: "ABCDEFGHIJ",
+			head:   3,
+			tail:   0,
+			want:   fmt.Sprintf("ABC%s", serr.EllipsisRune),
+		},
+		{
+			name:   "Tail only",
+			source: "ABCDEFGHIJ",
+			head:   0,
+			tail:   3,
+			want:   fmt.Sprintf("%sHIJ", serr.EllipsisRune),
+		},
+		{
+			name:   "Multibyte runes",
+			source: "日本語のテキストです",
+			head:   2,
+			tail:   3,
+			want:   fmt.Sprintf("日本%sトです", serr.EllipsisRune),
+		},
+		{
+			name:   "Head plus tail equals length",
+			source: "ABCDEFGHIJ",
+			head:   6,
+			tail:   4,
+			want:   "ABCDEFGHIJ",
+		},
+		{
+			name:   "Head plus tail exceeds length",
+			source: "ABCDEFGHIJ",
+			head:   8,
+			tail:   8,
+			want:   "ABCDEFGHIJ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serr.ExcerptParts(test.source, test.head, test.tail); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestExcerptReader(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		width  int
+	}{
+		{name: "Shorter than width", source: "ABCDEFGHIJ", width: 13},
+		{name: "Exactly width", source: "ABCDEFGHIJ", width: 10},
+		{name: "Odd width", source: "ABCDEFGHIJ", width: 7},
+		{name: "Even width", source: "ABCDEFGHIJ", width: 6},
+		{name: "Large input", source: Xs + "ABC" + strings.Repeat("x", 100000) + "XYZ", width: 9},
+		{name: "Multibyte runes", source: "日本語のテキストです", width: 5},
+		{name: "Width 1", source: "ABCDEFGHIJ", width: 1},
+		{name: "Width 0", source: "ABCDEFGHIJ", width: 0},
+		{name: "Negative width", source: "ABCDEFGHIJ", width: -1},
+		{name: "Negative width", source: "ABCDEFGHIJ", width: -2},
+	}
+	for _, test := range tests {
+		want := serr.Excerpt(test.source, test.width)
+		t.Run(test.name+"/Seekable", func(t *testing.T) {
+			got, err := serr.ExcerptReader(strings.NewReader(test.source), test.width)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+			}
+		})
+		t.Run(test.name+"/Not seekable", func(t *testing.T) {
+			got, err := serr.ExcerptReader(iotest.HalfReader(strings.NewReader(test.source)), test.width)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+			}
+		})
+	}
+}
+
+func TestExcerptAround(t *testing.T) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	var tests = []struct {
+		name   string
+		s      string
+		substr string
+		width  int
+		want   string
+	}{
+		{name: "Shorter than width", s: "ABCDEF", substr: "CD", width: 9, want: "ABCDEF"},
+		{name: "At start", s: alphabet, substr: "B", width: 9, want: "ABCDEFGH…"},
+		{name: "In middle", s: alphabet, substr: "MN", width: 9, want: "…KLMNOPQ…"},
+		{name: "At end", s: alphabet, substr: "YZ", width: 9, want: "…STUVWXYZ"},
+		{name: "Too long to fit", s: alphabet, substr: "HIJKLMNOPQ", width: 9, want: "…HIJKLMN…"},
+		{name: "Multibyte runes", s: "日本語のテキストはとても長いです", substr: "とても", width: 7, want: "…はとても長…"},
+		{name: "Not found", s: alphabet, substr: "xyz", width: 9, want: serr.Excerpt(alphabet, 9)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := serr.ExcerptAround(test.s, test.substr, test.width)
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestExcerptSmallWidths(t *testing.T) {
+	const long = "ABCDEFGHIJ"
+	var tests = []struct {
+		width int
+		want  string
+	}{
+		{width: -1, want: "…"},
+		{width: 0, want: "…"},
+		{width: 1, want: "…"},
+		{width: 2, want: "A…"},
+		{width: 3, want: "A…J"},
+		{width: 4, want: "AB…J"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("Width %d", test.width), func(t *testing.T) {
+			if got := serr.Excerpt(long, test.width); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+			if got := serr.ExcerptDisplay(long, test.width); test.want != got {
+				t.Errorf("ExcerptDisplay() not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+	if got := serr.Excerpt("", -1); got != "" {
+		t.Errorf("Excerpt() of an empty string should be empty; got %s", got)
+	}
+}
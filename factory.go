@@ -0,0 +1,45 @@
+package serr
+
+// ComponentKey is the key of the arg a Factory attaches to each error it
+// creates.
+const ComponentKey = "component"
+
+// Factory creates errors that carry the name of the subsystem or component
+// that created them. Create one with NewFactory().
+type Factory struct {
+	component string
+}
+
+// NewFactory returns a Factory whose errors carry component as an arg with
+// the key ComponentKey, e.g. one per package:
+//
+//	var errs = serr.NewFactory("billing")
+//	...
+//	return errs.Wrap(err, "charging card", "order_id", id)
+//
+//goland:noinspection GoUnusedExportedFunction
+func NewFactory(component string) *Factory {
+	return &Factory{component: component}
+}
+
+// Component returns the component the Factory was created with.
+func (f *Factory) Component() string {
+	return f.component
+}
+
+// New works like serr.New() but attaches the factory's component before args.
+// Pass args here rather than with a later .Args(), which replaces the args an
+// error has, component included.
+func (f *Factory) New(msg string, args ...any) SError {
+	return New(msg).Args(f.args(args)...)
+}
+
+// Wrap works like serr.Wrap(), returning nil if err is nil, but attaches the
+// factory's component before args.
+func (f *Factory) Wrap(err error, msg string, args ...any) SError {
+	return Wrap(err, msg, f.args(args)...)
+}
+
+func (f *Factory) args(args []any) []any {
+	return append([]any{ComponentKey, f.component}, args...)
+}
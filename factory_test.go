@@ -0,0 +1,51 @@
+package serr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestFactory(t *testing.T) {
+	f := serr.NewFactory("billing")
+	cause := errors.New("card declined")
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want string
+	}{
+		{
+			name: "New",
+			err:  f.New("invalid amount"),
+			want: "invalid amount [component='billing']",
+		},
+		{
+			name: "New with args",
+			err:  f.New("invalid amount", "amount", -5),
+			want: "invalid amount [component='billing'] [amount=-5]",
+		},
+		{
+			name: "Wrap",
+			err:  f.Wrap(cause, "charging card", "order_id", 42),
+			want: "charging card [component='billing'] [order_id=42]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+			attr, found := test.err.Attr(serr.ComponentKey)
+			if !found || attr.Value.String() != "billing" {
+				t.Errorf("Component arg missing\n\t\twant=%s\n\t\t got=%v", "billing", attr)
+			}
+		})
+	}
+	if err := f.Wrap(nil, "charging card"); err != nil {
+		t.Errorf("Wrap(nil) should be nil; got %v", err)
+	}
+	if !errors.Is(f.Wrap(cause, "charging card"), cause) {
+		t.Errorf("errors.Is() should find the wrapped error")
+	}
+}
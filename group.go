@@ -0,0 +1,127 @@
+package serr
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Group accumulates errors under a name, optionally nested in named child
+// groups, e.g. one child group per stage of a pipeline.
+type Group struct {
+	name   string
+	items  []groupItem
+	groups map[string]*Group
+}
+
+// groupItem is either an error or a child group.
+type groupItem struct {
+	err   error
+	group *Group
+}
+
+// NewGroup returns an empty Group with the given name.
+//
+//goland:noinspection GoUnusedExportedFunction
+func NewGroup(name string) *Group {
+	return &Group{
+		name:   name,
+		groups: make(map[string]*Group),
+	}
+}
+
+// Name returns the name the Group was created with.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// AddError adds err to the group. Nil errors are ignored.
+func (g *Group) AddError(err error) *Group {
+	if err != nil {
+		g.items = append(g.items, groupItem{err: err})
+	}
+	return g
+}
+
+// Group returns the child group with the given name, creating it on first use.
+func (g *Group) Group(name string) *Group {
+	child, ok := g.groups[name]
+	if !ok {
+		child = NewGroup(name)
+		g.groups[name] = child
+		g.items = append(g.items, groupItem{group: child})
+	}
+	return child
+}
+
+// Errors returns every error added to the group and its child groups, in the
+// order they were added.
+func (g *Group) Errors() (errs []error) {
+	for _, item := range g.items {
+		if item.group != nil {
+			errs = append(errs, item.group.Errors()...)
+			continue
+		}
+		errs = append(errs, item.err)
+	}
+	return errs
+}
+
+// Build returns an SError whose message renders the group as a tree, e.g.
+// "pipeline: stage1: (a; b); stage2: c", and which wraps every error in the
+// group so each is reachable by errors.Is() and errors.As(). Child groups with
+// more than one entry are parenthesized. Rendering stops with
+// NodesTruncatedMarker after Render.MaxNodes errors and child groups. Build
+// returns nil if the group holds no errors.
+func (g *Group) Build() SError {
+	var sErr SError
+	var remaining int
+	errs := g.Errors()
+	if len(errs) == 0 {
+		goto end
+	}
+	remaining = Render.MaxNodes
+	if remaining <= 0 {
+		remaining = math.MaxInt
+	}
+	sErr = New(g.name + ": " + g.render(&remaining)).Err(errors.Join(errs...))
+end:
+	return sErr
+}
+
+// hasErrors reports whether the group or any of its child groups holds an
+// error, without collecting them as Errors() does.
+func (g *Group) hasErrors() bool {
+	for _, item := range g.items {
+		if item.group == nil || item.group.hasErrors() {
+			return true
+		}
+	}
+	return false
+}
+
+// render renders the group's items, decrementing *remaining for each error
+// and child group rendered and stopping when it reaches zero.
+func (g *Group) render(remaining *int) string {
+	parts := make([]string, 0, len(g.items))
+	for _, item := range g.items {
+		if item.group != nil && !item.group.hasErrors() {
+			continue
+		}
+		if *remaining == 0 {
+			parts = append(parts, NodesTruncatedMarker)
+			break
+		}
+		*remaining--
+		if item.group == nil {
+			parts = append(parts, item.err.Error())
+			continue
+		}
+		part := item.group.render(remaining)
+		if len(item.group.items) > 1 {
+			part = "(" + part + ")"
+		}
+		parts = append(parts, item.group.name+": "+part)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,71 @@
+package serr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestGroup(t *testing.T) {
+	errFetch := serr.New("fetch failed").Args("url", "http://x")
+	errParse := errors.New("bad json")
+	errSave := serr.New("save failed")
+
+	t.Run("Empty", func(t *testing.T) {
+		g := serr.NewGroup("pipeline")
+		g.Group("stage1").AddError(nil)
+		if err := g.Build(); err != nil {
+			t.Errorf("Build() of an empty group should be nil; got %v", err)
+		}
+	})
+
+	t.Run("Two stages", func(t *testing.T) {
+		g := serr.NewGroup("pipeline")
+		g.Group("stage1").AddError(errFetch).AddError(errParse)
+		g.Group("stage2").AddError(errSave)
+		g.Group("stage3")
+		err := g.Build()
+		want := "pipeline: stage1: (fetch failed [url='http://x']; bad json); stage2: save failed"
+		if got := err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		for _, target := range []error{errFetch, errParse, errSave} {
+			if !errors.Is(err, target) {
+				t.Errorf("errors.Is() did not find %q", target)
+			}
+		}
+		if got := len(g.Errors()); got != 3 {
+			t.Errorf("Errors() length mismatch\n\t\twant=%d\n\t\t got=%d", 3, got)
+		}
+	})
+
+	t.Run("Same stage name reused", func(t *testing.T) {
+		g := serr.NewGroup("pipeline")
+		g.Group("stage1").AddError(errFetch)
+		g.Group("stage1").AddError(errSave)
+		want := "pipeline: stage1: (fetch failed [url='http://x']; save failed)"
+		if got := g.Build().Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+}
+
+func TestGroupMaxNodes(t *testing.T) {
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	serr.Render.MaxNodes = 3
+	g := serr.NewGroup("pipeline")
+	for i := 0; i < 1000; i++ {
+		g.Group("stage1").AddError(fmt.Errorf("error %d", i))
+	}
+	g.Group("stage2").AddError(errors.New("never rendered"))
+	err := g.Build()
+	want := "pipeline: stage1: (error 0; error 1; " + serr.NodesTruncatedMarker + "); " + serr.NodesTruncatedMarker
+	if got := err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := len(g.Errors()); got != 1001 {
+		t.Errorf("Errors() length mismatch\n\t\twant=%d\n\t\t got=%d", 1001, got)
+	}
+}
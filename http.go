@@ -0,0 +1,75 @@
+package serr
+
+import (
+	"sync"
+)
+
+// These mirror the net/http status constants, which serr does not import so
+// programs that use it need not link in net/http.
+const (
+	statusOK                  = 200
+	statusInternalServerError = 500
+)
+
+var (
+	codeStatusesMu sync.RWMutex
+	codeStatuses   = make(map[string]int)
+)
+
+// RegisterCodeStatus maps an error code set with .WithCode() to the HTTP
+// status that HTTPStatus() returns for errors with that code, e.g.
+// RegisterCodeStatus("NOT_FOUND", http.StatusNotFound), so the mapping is made
+// once rather than on every error.
+//
+//goland:noinspection GoUnusedExportedFunction
+func RegisterCodeStatus(code string, status int) {
+	codeStatusesMu.Lock()
+	defer codeStatusesMu.Unlock()
+	codeStatuses[code] = status
+}
+
+// WithHTTPStatus sets the HTTP status to respond with for the error,
+// overriding any status registered for its code.
+func (se *sError) WithHTTPStatus(status int) SError {
+	sErr := se.cloneWrap()
+	sErr.httpStatus = status
+	return sErr
+}
+
+// HTTPStatus returns the HTTP status to respond with for err: the status set
+// with .WithHTTPStatus() on the nearest error in its chain that has one,
+// otherwise the status registered with RegisterCodeStatus() for the nearest
+// code in its chain that has one, otherwise http.StatusInternalServerError. A
+// nil err yields http.StatusOK.
+//
+//goland:noinspection GoUnusedExportedFunction
+func HTTPStatus(err error) (status int) {
+	if err == nil {
+		status = statusOK
+		goto end
+	}
+	eachLayer(err, func(layer error) bool {
+		//goland:noinspection GoTypeAssertionOnErrors
+		if sErr, ok := layer.(*sError); ok {
+			status = sErr.httpStatus
+		}
+		return status == 0
+	})
+	if status != 0 {
+		goto end
+	}
+	codeStatusesMu.RLock()
+	defer codeStatusesMu.RUnlock()
+	eachLayer(err, func(layer error) bool {
+		//goland:noinspection GoTypeAssertionOnErrors
+		if sErr, ok := layer.(*sError); ok && sErr.code != "" {
+			status = codeStatuses[sErr.code]
+		}
+		return status == 0
+	})
+	if status == 0 {
+		status = statusInternalServerError
+	}
+end:
+	return status
+}
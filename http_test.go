@@ -0,0 +1,72 @@
+package serr_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	serr.RegisterCodeStatus("NOT_FOUND", http.StatusNotFound)
+	serr.RegisterCodeStatus("CONFLICT", http.StatusConflict)
+	var tests = []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "Registered code",
+			err:  serr.New("user not found").WithCode("NOT_FOUND"),
+			want: http.StatusNotFound,
+		},
+		{
+			name: "Registered code deeper in chain",
+			err:  serr.Wrap(fmt.Errorf("loading: %w", serr.New("exists").WithCode("CONFLICT")), "save failed"),
+			want: http.StatusConflict,
+		},
+		{
+			name: "Explicit status overrides code",
+			err:  serr.New("user not found").WithCode("NOT_FOUND").WithHTTPStatus(http.StatusGone),
+			want: http.StatusGone,
+		},
+		{
+			name: "Explicit status deeper in chain",
+			err:  serr.Wrap(serr.New("bad input").WithHTTPStatus(http.StatusBadRequest), "save failed").WithCode("NOT_FOUND"),
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "Unregistered code",
+			err:  serr.New("odd").WithCode("UNKNOWN_CODE"),
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "No code",
+			err:  serr.Wrap(errors.New("boom"), "failed"),
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "Standard error with explicit status",
+			err:  fmt.Errorf("retrying: %w", serr.Cast(errors.New("rate limited")).WithHTTPStatus(http.StatusTooManyRequests)),
+			want: http.StatusTooManyRequests,
+		},
+		{
+			name: "Standard error",
+			err:  errors.New("boom"),
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "Nil",
+			want: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serr.HTTPStatus(test.err); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%d\n\t\t got=%d", test.want, got)
+			}
+		})
+	}
+}
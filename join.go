@@ -0,0 +1,189 @@
+package serr
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Join returns an SError that wraps errors.Join() of the non-nil errs, so each
+// of errs remains reachable by errors.Is() and errors.As(). Its message is the
+// newline-separated messages of errs, limited by Render.MaxNodes. Join returns
+// nil if every one of errs is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Join(errs ...error) SError {
+	var sErr SError
+	var msgs []string
+	joined := errors.Join(errs...)
+	if joined == nil {
+		goto end
+	}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if Render.MaxNodes > 0 && len(msgs) == Render.MaxNodes {
+			msgs = append(msgs, NodesTruncatedMarker)
+			break
+		}
+		msgs = append(msgs, err.Error())
+	}
+	sErr = New(strings.Join(msgs, "\n")).Err(joined)
+end:
+	return sErr
+}
+
+// Combine returns an SError merging a and b: its message is their base
+// messages separated by "; ", its args are the union of the args attached to
+// each, and it wraps errors.Join(a, b) so that errors.Is() and errors.As()
+// search both of their chains. When a and b attach the same key the value from
+// a is kept. If either is nil Combine returns the other.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Combine(a, b SError) (sErr SError) {
+	var args []any
+	switch {
+	case a == nil:
+		sErr = b
+		goto end
+	case b == nil:
+		sErr = a
+		goto end
+	}
+	args = slices.Clone(a.GetArgs())
+	for i, bArgs := 0, b.GetArgs(); i < len(bArgs)-1; i += 2 {
+		if slices.Contains(argKeys(args), fmt.Sprintf("%v", bArgs[i])) {
+			continue
+		}
+		args = append(args, bArgs[i], bArgs[i+1])
+	}
+	sErr = &sError{
+		error: errors.New(a.BaseMessage() + "; " + b.BaseMessage()),
+		id:    nextID.Add(1),
+		err:   errors.Join(a, b),
+		args:  args,
+	}
+end:
+	return sErr
+}
+
+// Collect calls each of fns in order and returns the errors they return joined
+// via Join(), or nil if every one of fns succeeds.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Collect(fns ...func() error) SError {
+	errs := make([]error, 0, len(fns))
+	for _, fn := range fns {
+		errs = append(errs, fn())
+	}
+	return Join(errs...)
+}
+
+// SafeErrorList collects errors from multiple goroutines. Create one with
+// SafeList().
+type SafeErrorList struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// SafeList returns an empty SafeErrorList.
+//
+//goland:noinspection GoUnusedExportedFunction
+func SafeList() *SafeErrorList {
+	return &SafeErrorList{}
+}
+
+// Add adds err to the list. Nil errors are ignored. Add is safe to call from
+// multiple goroutines.
+func (l *SafeErrorList) Add(err error) {
+	if err == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs = append(l.errs, err)
+}
+
+// Len returns the number of errors added so far.
+func (l *SafeErrorList) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.errs)
+}
+
+// Err returns the errors added so far joined via Join(), or nil if none were
+// added.
+func (l *SafeErrorList) Err() SError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Join(l.errs...)
+}
+
+// Map returns the result of calling fn on each non-nil error in errs, in
+// order. Nil errors are skipped rather than passed to fn.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Map(errs []error, fn func(error) SError) []SError {
+	sErrs := make([]SError, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		sErrs = append(sErrs, fn(err))
+	}
+	return sErrs
+}
+
+// MapWrap wraps each non-nil error in errs with msg and args using Wrap().
+//
+//goland:noinspection GoUnusedExportedFunction
+func MapWrap(errs []error, msg string, args ...any) []SError {
+	return Map(errs, func(err error) SError {
+		return Wrap(err, msg, args...)
+	})
+}
+
+// Dedup returns errs with every error whose Error() matches that of an earlier
+// error removed, preserving order. Nil errors are removed.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Dedup(errs []error) []error {
+	deduped, _ := dedup(errs)
+	return deduped
+}
+
+// DedupCount works like Dedup but attaches to each error that occurred more
+// than once an arg with the key CountKey holding the number of occurrences,
+// using Enrich().
+//
+//goland:noinspection GoUnusedExportedFunction
+func DedupCount(errs []error) []error {
+	deduped, counts := dedup(errs)
+	for i, err := range deduped {
+		if counts[i] > 1 {
+			deduped[i] = Enrich(err, CountKey, counts[i])
+		}
+	}
+	return deduped
+}
+
+func dedup(errs []error) (deduped []error, counts []int) {
+	index := make(map[string]int)
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		msg := err.Error()
+		if i, found := index[msg]; found {
+			counts[i]++
+			continue
+		}
+		index[msg] = len(deduped)
+		deduped = append(deduped, err)
+		counts = append(counts, 1)
+	}
+	return deduped, counts
+}
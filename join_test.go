@@ -0,0 +1,226 @@
+package serr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestJoin(t *testing.T) {
+	errA := serr.New("a failed")
+	errB := errors.New("b failed")
+	t.Run("All nil", func(t *testing.T) {
+		if err := serr.Join(nil, nil); err != nil {
+			t.Errorf("Join() of nil errors should be nil; got %v", err)
+		}
+	})
+	t.Run("Mixed", func(t *testing.T) {
+		err := serr.Join(errA, nil, errB)
+		if want, got := "a failed\nb failed", err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		if !errors.Is(err, errA) || !errors.Is(err, errB) {
+			t.Errorf("errors.Is() should find both joined errors")
+		}
+	})
+}
+
+func TestJoinMaxNodes(t *testing.T) {
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	serr.Render.MaxNodes = 3
+	errs := make([]error, 10000)
+	for i := range errs {
+		errs[i] = fmt.Errorf("error %d", i)
+	}
+	err := serr.Join(errs...)
+	want := "error 0\nerror 1\nerror 2\n" + serr.NodesTruncatedMarker
+	if got := err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if !errors.Is(err, errs[9999]) {
+		t.Errorf("errors.Is() should still find errors beyond the budget")
+	}
+}
+
+func TestCombine(t *testing.T) {
+	sentinelA := errors.New("disk full")
+	sentinelB := serr.New("quota exceeded")
+	a := serr.Wrap(sentinelA, "write failed", "path", "/tmp/x", "size", 10)
+	b := serr.Wrap(sentinelB.Args("user", "alice"), "upload failed", "size", 20, "user", "bob")
+
+	t.Run("Nil", func(t *testing.T) {
+		if got := serr.Combine(a, nil); got != a {
+			t.Errorf("Combine(a, nil) should return a; got %v", got)
+		}
+		if got := serr.Combine(nil, b); got != b {
+			t.Errorf("Combine(nil, b) should return b; got %v", got)
+		}
+	})
+	t.Run("Both", func(t *testing.T) {
+		err := serr.Combine(a, b)
+		want := "write failed; upload failed [path='/tmp/x'] [size=10] [user='bob']"
+		if got := err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		if !errors.Is(err, sentinelA) || !errors.Is(err, sentinelB) {
+			t.Errorf("errors.Is() should find the sentinels of both chains")
+		}
+		if !errors.Is(err, a) || !errors.Is(err, b) {
+			t.Errorf("errors.Is() should find both combined errors")
+		}
+	})
+}
+
+func TestCollect(t *testing.T) {
+	errA := serr.New("a failed")
+	errB := errors.New("b failed")
+	succeed := func() error { return nil }
+	var tests = []struct {
+		name     string
+		fns      []func() error
+		wantNil  bool
+		wantErrs []error
+	}{
+		{
+			name:    "No funcs",
+			wantNil: true,
+		},
+		{
+			name:    "All succeed",
+			fns:     []func() error{succeed, succeed},
+			wantNil: true,
+		},
+		{
+			name: "Partial failure",
+			fns: []func() error{
+				succeed,
+				func() error { return errA },
+				succeed,
+				func() error { return errB },
+			},
+			wantErrs: []error{errA, errB},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := serr.Collect(test.fns...)
+			if (err == nil) != test.wantNil {
+				t.Fatalf("Nil mismatch\n\t\twant=%t\n\t\t got=%t", test.wantNil, err == nil)
+			}
+			for _, want := range test.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("errors.Is() did not find %q in %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSafeList(t *testing.T) {
+	const workers = 50
+	list := serr.SafeList()
+	if list.Err() != nil {
+		t.Errorf("Err() of an empty list should be nil")
+	}
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range errs {
+		errs[i] = serr.New("worker failed").Args("worker", i)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			list.Add(errs[i])
+			list.Add(nil)
+		}(i)
+	}
+	wg.Wait()
+	if got := list.Len(); got != workers {
+		t.Errorf("Len() mismatch\n\t\twant=%d\n\t\t got=%d", workers, got)
+	}
+	err := list.Err()
+	for _, want := range errs {
+		if !errors.Is(err, want) {
+			t.Errorf("errors.Is() did not find %q", want)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := serr.New("b failed")
+	errs := []error{nil, errA, nil, errB, nil}
+	t.Run("Map", func(t *testing.T) {
+		got := serr.Map(errs, func(err error) serr.SError {
+			return serr.Cast(err, "batch", 1)
+		})
+		if len(got) != 2 {
+			t.Fatalf("Length mismatch\n\t\twant=%d\n\t\t got=%d", 2, len(got))
+		}
+		if want := "a failed [batch=1]"; got[0].Error() != want {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got[0].Error())
+		}
+	})
+	t.Run("MapWrap", func(t *testing.T) {
+		got := serr.MapWrap(errs, "processing batch", "batch", 1)
+		if len(got) != 2 {
+			t.Fatalf("Length mismatch\n\t\twant=%d\n\t\t got=%d", 2, len(got))
+		}
+		for i, want := range []error{errA, errB} {
+			if got[i].Error() != "processing batch [batch=1]" {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", "processing batch [batch=1]", got[i].Error())
+			}
+			if !errors.Is(got[i], want) {
+				t.Errorf("errors.Is() did not find %q", want)
+			}
+		}
+	})
+}
+
+func TestDedup(t *testing.T) {
+	errs := []error{
+		errors.New("timeout"),
+		serr.New("refused").Args("port", 80),
+		nil,
+		errors.New("timeout"),
+		serr.New("refused").Args("port", 443),
+		errors.New("timeout"),
+	}
+	render := func(errs []error) []string {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return msgs
+	}
+	t.Run("Dedup", func(t *testing.T) {
+		want := []string{"timeout", "refused [port=80]", "refused [port=443]"}
+		if got := render(serr.Dedup(errs)); !slices.Equal(want, got) {
+			t.Errorf("Dedup() mismatch\n\t\twant=%q\n\t\t got=%q", want, got)
+		}
+	})
+	t.Run("DedupCount", func(t *testing.T) {
+		want := []string{"timeout [count=3]", "refused [port=80]", "refused [port=443]"}
+		if got := render(serr.DedupCount(errs)); !slices.Equal(want, got) {
+			t.Errorf("DedupCount() mismatch\n\t\twant=%q\n\t\t got=%q", want, got)
+		}
+	})
+	t.Run("DedupCount strict sentinel", func(t *testing.T) {
+		errNotFound := serr.NewSentinel("not found", "id")
+		want := []string{"not found [count=2]"}
+		if got := render(serr.DedupCount([]error{errNotFound, errNotFound})); !slices.Equal(want, got) {
+			t.Errorf("DedupCount() mismatch\n\t\twant=%q\n\t\t got=%q", want, got)
+		}
+	})
+	t.Run("DedupCount keeps chains", func(t *testing.T) {
+		errTimeout := fmt.Errorf("calling api: %w", context.DeadlineExceeded)
+		deduped := serr.DedupCount([]error{errTimeout, errTimeout})
+		if len(deduped) != 1 || !errors.Is(deduped[0], context.DeadlineExceeded) {
+			t.Errorf("DedupCount() lost the wrapped error: %v", deduped)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package serr
+
+import (
+	"regexp"
+	"sync"
+)
+
+// mask replaces the text of arg values matching re with replacement.
+type mask struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+var (
+	masksMu sync.RWMutex
+	masks   []mask
+)
+
+// RegisterMask makes Error(), Attrs(), AllAttrs(), AsRecords() and MarshalJSON()
+// replace text in arg values matching re with replacement, as
+// regexp.ReplaceAllString() does, e.g. to scrub email addresses or card numbers
+// that were attached by mistake. Masks apply to every error, in the order
+// registered, and are safe to register concurrently with rendering, though
+// typically they are registered in init().
+//
+//	serr.RegisterMask(regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`), "[email]")
+//
+//goland:noinspection GoUnusedExportedFunction
+func RegisterMask(re *regexp.Regexp, replacement string) {
+	masksMu.Lock()
+	defer masksMu.Unlock()
+	masks = append(masks, mask{re: re, replacement: replacement})
+}
+
+// maskValue applies every registered mask to s.
+func maskValue(s string) string {
+	masksMu.RLock()
+	defer masksMu.RUnlock()
+	for _, m := range masks {
+		s = m.re.ReplaceAllString(s, m.replacement)
+	}
+	return s
+}
+
+// maskArg applies every registered mask to value if it is a string. Other
+// values are returned as is so that slog handlers see their original type.
+func maskArg(value any) any {
+	if s, ok := value.(string); ok {
+		value = maskValue(s)
+	}
+	return value
+}
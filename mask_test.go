@@ -0,0 +1,46 @@
+package serr_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestRegisterMask(t *testing.T) {
+	// Masks cannot be unregistered, so this pattern only matches addresses at
+	// a domain no other test uses.
+	serr.RegisterMask(regexp.MustCompile(`[\w.+-]+@mask\.test`), "[email]")
+
+	err := serr.New("signup failed").Args(
+		"contact", "Alice <alice.smith@mask.test>",
+		"user", "alice",
+		"attempts", 3,
+	)
+
+	want := "signup failed [contact='Alice <[email]>'] [user='alice'] [attempts=3]"
+	if got := err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+
+	attr, _ := err.Attr("contact")
+	if want, got := "Alice <[email]>", attr.Value.String(); want != got {
+		t.Errorf("Attr() not masked\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	attr, _ = err.Attr("attempts")
+	if got := attr.Value.Any(); got != int64(3) {
+		t.Errorf("Attr() of a non-string should keep its value; got %v", got)
+	}
+	for _, attr := range err.AllAttrs() {
+		if attr.Key == "contact" && attr.Value.String() != "Alice <[email]>" {
+			t.Errorf("AllAttrs() not masked; got %s", attr.Value.String())
+		}
+	}
+	args := err.AsRecords()[0]["args"].(map[string]any)
+	if want, got := "Alice <[email]>", args["contact"]; want != got {
+		t.Errorf("AsRecords() not masked\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := args["attempts"]; got != 3 {
+		t.Errorf("AsRecords() of a non-string should keep its value; got %v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package serr
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+)
+
+const (
+	PanicTypeKey  = "panic_type"
+	PanicValueKey = "panic_value"
+	StackKey      = "stack"
+)
+
+// FromPanic converts a value returned by recover() into an SError with args
+// for the value's dynamic type, the value itself, and the stack at the time
+// FromPanic was called. When r is an error it is wrapped as the cause. FromPanic
+// returns nil if r is nil.
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = serr.FromPanic(r)
+//		}
+//	}()
+//
+//goland:noinspection GoUnusedExportedFunction
+func FromPanic(r any) SError {
+	var sErr SError
+	var args []any
+	if r == nil {
+		goto end
+	}
+	args = []any{
+		PanicTypeKey, reflect.TypeOf(r).String(),
+		PanicValueKey, r,
+		StackKey, string(debug.Stack()),
+	}
+	if err, ok := r.(error); ok {
+		sErr = Wrap(err, "panic: "+err.Error(), args...)
+		goto end
+	}
+	sErr = New(fmt.Sprintf("panic: %v", r)).Args(args...)
+end:
+	return sErr
+}
@@ -0,0 +1,79 @@
+package serr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+type panicStruct struct {
+	Code int
+}
+
+func TestFromPanic(t *testing.T) {
+	errBoom := errors.New("boom")
+	var tests = []struct {
+		name      string
+		value     any
+		wantMsg   string
+		wantType  string
+		wantCause error
+	}{
+		{
+			name:      "Error",
+			value:     errBoom,
+			wantMsg:   "panic: boom",
+			wantType:  "*errors.errorString",
+			wantCause: errBoom,
+		},
+		{
+			name:     "String",
+			value:    "out of range",
+			wantMsg:  "panic: out of range",
+			wantType: "string",
+		},
+		{
+			name:     "Struct",
+			value:    panicStruct{Code: 42},
+			wantMsg:  "panic: {42}",
+			wantType: "serr_test.panicStruct",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := recoverPanic(test.value)
+			if got := err.String(); got != test.wantMsg {
+				t.Errorf("Message mismatch\n\t\twant=%s\n\t\t got=%s", test.wantMsg, got)
+			}
+			attr, _ := err.Attr(serr.PanicTypeKey)
+			if got := attr.Value.String(); got != test.wantType {
+				t.Errorf("Type mismatch\n\t\twant=%s\n\t\t got=%s", test.wantType, got)
+			}
+			attr, _ = err.Attr(serr.PanicValueKey)
+			if got := attr.Value.Any(); got != test.value {
+				t.Errorf("Value mismatch\n\t\twant=%v\n\t\t got=%v", test.value, got)
+			}
+			attr, _ = err.Attr(serr.StackKey)
+			if !strings.Contains(attr.Value.String(), "recoverPanic") {
+				t.Errorf("Stack does not include the recovering function:\n%s", attr.Value.String())
+			}
+			if test.wantCause != nil && !errors.Is(err, test.wantCause) {
+				t.Errorf("errors.Is() did not find the panicked error")
+			}
+		})
+	}
+	t.Run("Nil", func(t *testing.T) {
+		if err := serr.FromPanic(nil); err != nil {
+			t.Errorf("FromPanic(nil) should be nil; got %v", err)
+		}
+	})
+}
+
+func recoverPanic(value any) (err serr.SError) {
+	defer func() {
+		err = serr.FromPanic(recover())
+	}()
+	panic(value)
+}
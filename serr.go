@@ -1,21 +1,146 @@
 package serr
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"reflect"
+	"runtime"
 	"slices"
 	"strings"
-	//"sync"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
 const (
-	ExcerptFormat      = "%s%s%s"
-	LengthPrefixFormat = "[len=%d] %s"
-	EllipsisRune       = "\u2026"
+	ExcerptFormat        = "%s%s%s"
+	LengthPrefixFormat   = "[len=%d] %s"
+	EllipsisRune         = "\u2026"
+	RequestIDKey         = "request_id"
+	HelpURLFormat        = " (see: %s)"
+	FuncKey              = "func"
+	CountKey             = "count"
+	ArgCountFormat       = " (%d %s)"
+	TruncatedMarker      = "[truncated]"
+	MissingFormat        = " (missing required field: %s)"
+	NodesTruncatedMarker = "…(truncated)"
+	MissingKey           = "missing_required"
 )
 
+// ArgsFormat selects how Error() renders the args attached to an SError.
+type ArgsFormat int
+
+const (
+	// BracketArgs renders each arg as ` [key=value]` using the delimiters in
+	// RenderConfig.
+	BracketArgs ArgsFormat = iota
+	// JSONArgs renders args as a trailing JSON object with its keys sorted,
+	// e.g. ` {"count":3,"path":"/tmp"}`.
+	JSONArgs
+)
+
+// RenderConfig controls how Error() renders the args attached to an SError.
+type RenderConfig struct {
+	// ArgsFormat selects bracketed or JSON rendering of args.
+	ArgsFormat ArgsFormat
+	// ArgOpen and ArgClose delimit each key-value pair, e.g. "[" and "]".
+	ArgOpen  string
+	ArgClose string
+	// ArgSeparator separates a key from its value, e.g. "=".
+	ArgSeparator string
+	// LeadingSpace precedes the first arg, separating args from the message.
+	LeadingSpace string
+	// ArgSpacing precedes each arg after the first.
+	ArgSpacing string
+	// MaxValueLen excerpts rendered values longer than this many runes. Zero
+	// means no limit.
+	MaxValueLen int
+	// KeyMaxValueLen overrides MaxValueLen for specific keys. A zero entry
+	// means no limit for that key.
+	KeyMaxValueLen map[string]int
+	// InnermostFirst makes ChainError() and TypedError() render the root cause
+	// first and the outermost error last.
+	InnermostFirst bool
+	// ShowHelpURL appends the URL set by .WithHelpURL() to Error() using
+	// HelpURLFormat.
+	ShowHelpURL bool
+	// MaxWrappedLen excerpts the message of a non-SError wrapped by, or adopted
+	// by Cast() or Enrich() as, an SError when it is longer than this many
+	// runes. Zero means no limit.
+	MaxWrappedLen int
+	// MaxErrorLen excerpts the whole string returned by Error() when it is
+	// longer than this many runes, bounding the length of log lines. Zero
+	// means no limit.
+	MaxErrorLen int
+	// ShowArgCount inserts the number of args attached to each error after its
+	// message using ArgCountFormat, e.g. "msg (3 fields) [k=v] ...".
+	ShowArgCount bool
+	// TruncationMarker, when not empty, makes the caps above cut values and
+	// messages short at the end and append the marker, e.g. TruncatedMarker,
+	// rather than excerpting them around EllipsisRune, so that forced
+	// truncation is distinguishable from excerpting in logs. The marker counts
+	// toward the cap, and is itself cut if the cap is shorter than it.
+	TruncationMarker string
+	// MaxNodes limits the number of errors rendered into the message of an
+	// error built by Join() or Group.Build(), counting each error and child
+	// group once, after which NodesTruncatedMarker is rendered instead of the
+	// rest. This bounds the cost of rendering very wide error trees. Zero means
+	// no limit.
+	MaxNodes int
+}
+
+// DefaultRenderConfig renders args as ` [key=value]`.
+var DefaultRenderConfig = RenderConfig{
+	ArgOpen:      "[",
+	ArgClose:     "]",
+	ArgSeparator: "=",
+	LeadingSpace: " ",
+	ArgSpacing:   " ",
+}
+
+// Render is the RenderConfig consulted by Error(). Assign to it to change how
+// args are rendered package-wide.
+var Render = DefaultRenderConfig
+
+// capValue excerpts value if it exceeds the maximum length configured for key.
+func (rc RenderConfig) capValue(key, value string) string {
+	maxLen, ok := rc.KeyMaxValueLen[key]
+	if !ok {
+		maxLen = rc.MaxValueLen
+	}
+	if maxLen > 0 {
+		value = rc.truncate(value, maxLen)
+	}
+	return value
+}
+
+// truncate shortens s to maxLen runes, by excerpting it or, if a
+// TruncationMarker is configured, by cutting it and appending the marker.
+func (rc RenderConfig) truncate(s string, maxLen int) string {
+	if rc.TruncationMarker == "" {
+		return Excerpt(s, maxLen)
+	}
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	head := max(maxLen-utf8.RuneCountInString(rc.TruncationMarker), 0)
+	return prefixRunes(prefixRunes(s, head)+rc.TruncationMarker, maxLen)
+}
+
+// SError is an error carrying args and other metadata. Methods set or read the
+// error's own state, with setters returning a modified clone; functions that
+// adapt any error for output, such as HTTPStatus() and RenderWith(), are
+// package functions taking an error so they also work on errors from elsewhere.
 type SError interface {
 	error
 	GetArgs() []any
@@ -25,33 +150,260 @@ type SError interface {
 	Err(error, ...any) SError
 	Unwrap() error
 	ValidArgs(...string) SError
+	ValidArgsTyped(map[string]reflect.Kind) SError
 	NoArgs() SError
 	String() string
 	IsNil() bool
 	Clone() SError
 	CloneWrap() SError
 	CloneUnwrap() error
+	ArgCount() int
+	TotalArgCount() int
+	WithCode(string) SError
+	Code() string
+	IsCode(string) bool
+	LazyArg(string, func() any) SError
+	WithRequestID(string) SError
+	RequestID() (string, bool)
+	WithPrefix(string) SError
+	Plain() error
+	FreezeMessage() SError
+	TypedError() string
+	ChainError() string
+	Op(string) SError
+	Ops() []string
+	WithRetryable(bool) SError
+	Retryable() bool
+	WithLevel(slog.Level) SError
+	Level() slog.Level
+	Classify() Classification
+	AsRecords() []map[string]any
+	HasCycle() bool
+	BaseMessage() string
+	BaseMessages() []string
+	WithHelpURL(string) SError
+	HelpURL() (string, bool)
+	ArgKeys() []string
+	AllArgKeys() []string
+	HideArgsInError() SError
+	AllAttrs() []slog.Attr
+	WithRetryAfter(time.Duration) SError
+	RetryAfter() (time.Duration, bool)
+	ChainDepth() int
+	Logged() SError
+	IsLogged() bool
+	SetArg(string, any) SError
+	Walk(func(SError) bool)
+	WalkDepth(func(int, SError) bool)
+	Tag(string, string) SError
+	Tags() map[string]string
+	SnapshotArgs() func() SError
+	WithRelated(string, error) SError
+	Related() map[string]error
+	FindArg(func(string, any) bool) (string, any, bool)
+	MetricArgs(...any) SError
+	MetricLabels() map[string]string
+	Fingerprint() string
+	RangeArgs(func(string, any) bool)
+	LongMessage(string) SError
+	PrettyVerbose() string
+	Summary() string
+	SafeArgs() SError
+	WithHTTPStatus(int) SError
+	CleanMessage() string
+}
+
+// Classification summarizes the attributes of an error used to decide whether
+// to retry, alert or log it. See SError.Classify().
+type Classification struct {
+	Code      string
+	Retryable bool
+	Level     slog.Level
 }
 
-var _ SError = (*sError)(nil)
+// ArgsProvider can be implemented by error types that are not SErrors so the
+// key-value pairs they carry are included wherever serr walks a chain, e.g. by
+// TotalArgCount() and AsRecords(). Its method is named ErrorArgs rather than
+// Args to avoid confusion with SError.Args().
+type ArgsProvider interface {
+	ErrorArgs() []any
+}
+
+var (
+	_ SError         = (*sError)(nil)
+	_ json.Marshaler = (*sError)(nil)
+)
 
 type sError struct {
 	error
+	id           uint64
 	err          error
+	code         string
+	prefix       string
+	ops          []string
+	helpURL      string
+	tags         map[string]string
+	related      map[string]error
+	metricKeys   []string
+	longMessage  string
+	onceKeys     []string
+	safeArgs     *sync.Once
+	httpStatus   int
+	retryable    *bool
+	retryAfter   *time.Duration
+	level        *slog.Level
 	args         []any
 	validArgs    []string
-	recurs       []*sError
+	argKinds     map[string]reflect.Kind
 	sealed       bool
+	strict       bool
+	cast         bool
+	hideArgs     bool
+	logged       bool
 	locked       bool
 	cloneWrapped bool
 }
 
+// MetricSafeKeys lists arg keys whose values are always safe to use as metric
+// labels, i.e. have low cardinality, in addition to those attached with
+// .MetricArgs(). See SError.MetricLabels().
+var MetricSafeKeys []string
+
+// FingerprintValues makes Fingerprint() include arg values as well as keys,
+// for when values identify the kind of error rather than an occurrence of it.
+var FingerprintValues = false
+
+// SummaryKeys lists, in order, the keys of the args that Summary() includes.
+var SummaryKeys []string
+
+// SummaryWidth is the width in runes beyond which Summary() excerpts its
+// result.
+var SummaryWidth = 80
+
+// RequireValidArgs treats the keys declared with .ValidArgs(), e.g. by
+// NewSentinel(), as required: Error() appends MissingFormat naming any that
+// the error lacks and Attrs() adds an attr with the key MissingKey listing
+// them, rather than silently omitting them. By default they are optional.
+var RequireValidArgs = false
+
+// InnerAttrsOverride makes AllAttrs() prefer the value of an arg attached
+// deeper in the chain over one with the same key attached nearer the top.
+var InnerAttrsOverride = false
+
+// StrictArgs makes .Args() panic when passed an odd number of args, which is
+// the default so that misuse fails fast during development. When false, the
+// trailing arg is logged with slog.Warn() and dropped instead.
+var StrictArgs = true
+
+// StrictMessages makes New() panic when passed an empty message. By default an
+// empty message is allowed and Error() renders just its args.
+var StrictMessages = false
+
 func New(msg string) SError {
+	if StrictMessages && msg == "" {
+		panicf("serr.New() requires a non-empty message when serr.StrictMessages is true")
+	}
 	return &sError{
 		error: errors.New(msg),
+		id:    nextID.Add(1),
 	}
 }
 
+// defaultArgs are the args set by SetDefaults().
+var defaultArgs []any
+
+// SetDefaults sets args that every error renders, e.g. the name and version of
+// the service. They are added once, to the outermost error, when it is
+// rendered by Error(), ChainError(), Attrs(), AllAttrs(), MarshalJSON() and the
+// like, so sentinels declared before SetDefaults is called get them too. Args
+// attached with .Args() override a default with the same key and are rendered
+// before the defaults. Call SetDefaults during initialization, before errors
+// are rendered concurrently; call it with no args to clear the defaults. Like .Args() it panics if args is not key-value pairs when
+// StrictArgs is true.
+//
+//goland:noinspection GoUnusedExportedFunction
+func SetDefaults(args ...any) {
+	args = (&sError{error: errors.New("serr.SetDefaults()")}).chkArgs(args)
+	defaultArgs = slices.Clip(slices.Clone(args))
+}
+
+// nextID issues the identity token New() gives each error, which its clones
+// share so that errors.Is() can match them against each other.
+var nextID atomic.Uint64
+
+// BadKey is the key NewWithAttrs() gives a value that is not preceded by a
+// string key, matching the key log/slog uses.
+const BadKey = "!BADKEY"
+
+// NewWithAttrs works like New but accepts args as log/slog's variadic logging
+// methods do: each slog.Attr is attached as its key and value, each string is
+// taken as a key for the value that follows it, and a value without a key,
+// including a trailing string, is attached with the key BadKey.
+//
+//	serr.NewWithAttrs("failed", slog.Int("id", 1), "table", "users")
+//
+//goland:noinspection GoUnusedExportedFunction
+func NewWithAttrs(msg string, attrs ...any) SError {
+	var args []any
+	for len(attrs) > 0 {
+		switch a := attrs[0].(type) {
+		case slog.Attr:
+			args = append(args, a.Key, a.Value.Any())
+			attrs = attrs[1:]
+		case string:
+			if len(attrs) == 1 {
+				args = append(args, BadKey, a)
+				attrs = attrs[1:]
+				break
+			}
+			args = append(args, a, attrs[1])
+			attrs = attrs[2:]
+		default:
+			args = append(args, BadKey, a)
+			attrs = attrs[1:]
+		}
+	}
+	sErr := New(msg)
+	if len(args) > 0 {
+		sErr = sErr.Args(args...)
+	}
+	return sErr
+}
+
+// CaptureCaller makes NewHere() attach the name of its calling function. Set
+// it to false to avoid the cost of runtime.Caller() where that matters.
+var CaptureCaller = true
+
+// NewHere works like New but attaches the fully-qualified name of the function
+// that called it as an arg with the key FuncKey, when CaptureCaller is true.
+//
+//goland:noinspection GoUnusedExportedFunction
+func NewHere(msg string) SError {
+	var fn *runtime.Func
+	sErr := New(msg)
+	if !CaptureCaller {
+		goto end
+	}
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		fn = runtime.FuncForPC(pc)
+	}
+	if fn != nil {
+		sErr = sErr.Args(FuncKey, fn.Name())
+	}
+end:
+	return sErr
+}
+
+// NewSentinel creates an SError whose .Args() panics if passed any key not
+// listed in validArgs.
+//
+//goland:noinspection GoUnusedExportedFunction
+func NewSentinel(msg string, validArgs ...string) SError {
+	sErr := New(msg).ValidArgs(validArgs...).(*sError)
+	sErr.strict = true
+	return sErr
+}
+
 func (se *sError) IsNil() bool {
 	return se.error == nil
 }
@@ -60,22 +412,133 @@ func (se *sError) String() string {
 	return se.error.Error()
 }
 
+// BaseMessage returns the error's message without its args, operation names or
+// wrapped errors, e.g. for grouping errors by message in metrics.
+func (se *sError) BaseMessage() (msg string) {
+	msg = se.error.Error()
+	if se.cast {
+		msg = wrappedMessage(msg)
+	}
+	return msg
+}
+
+// wrappedMessage returns msg, the message of a non-SError in a chain, excerpted
+// to Render.MaxWrappedLen if set.
+func wrappedMessage(msg string) string {
+	if Render.MaxWrappedLen > 0 {
+		msg = Render.truncate(msg, Render.MaxWrappedLen)
+	}
+	return msg
+}
+
+// BaseMessages returns the base message of each logical layer of the chain,
+// outermost first. Non-SError layers contribute the result of their Error().
+func (se *sError) BaseMessages() []string {
+	layers := chain(se)
+	msgs := make([]string, len(layers))
+	for i, err := range layers {
+		//goland:noinspection GoTypeAssertionOnErrors
+		if sErr, ok := err.(*sError); ok {
+			msgs[i] = sErr.BaseMessage()
+			continue
+		}
+		msgs[i] = wrappedMessage(err.Error())
+	}
+	return msgs
+}
+
+// Error renders the error's own statement as CleanMessage() does, followed by
+// its help URL if Render.ShowHelpURL is true, and excerpted to
+// Render.MaxErrorLen if set. Nothing is rendered from the errors it wraps; use
+// ChainError() for that.
 func (se *sError) Error() (s string) {
-	if se.err == nil {
-		s = se.error.Error() + se.argsString()
-		goto end
+	s = se.message()
+	if url, ok := se.HelpURL(); ok && Render.ShowHelpURL {
+		s += fmt.Sprintf(HelpURLFormat, url)
+	}
+	if Render.MaxErrorLen > 0 {
+		s = Render.truncate(s, Render.MaxErrorLen)
+	}
+	return s
+}
+
+// CleanMessage returns only this error's own statement: its operation names,
+// message and rendered args. It differs from Error() only in never adding the
+// help URL or applying the MaxErrorLen excerpting that Error() may apply, so
+// it is safe to compare or embed regardless of Render settings.
+func (se *sError) CleanMessage() string {
+	return se.message()
+}
+
+// message returns the error's message followed by its rendered args. When the
+// message is empty the args are rendered without a leading space.
+//
+// Operation names added by .Op() are prepended most recent first, e.g.
+// "ReadFile: LoadConfig: <msg>", with no trailing ": " when <msg> is empty.
+func (se *sError) message() string {
+	return se.paintedMessage(palette{}, se.argsWithDefaults())
+}
+
+// innerMessage works like message but without the default args, for rendering
+// the error as a layer wrapped by another error that renders them.
+func (se *sError) innerMessage() string {
+	return se.paintedMessage(palette{}, se.args)
+}
+
+// paintedMessage works like message but renders args and colors the message
+// and arg keys using the ANSI codes in p.
+func (se *sError) paintedMessage(p palette, args []any) (msg string) {
+	msg = se.BaseMessage()
+	if n := len(args) / 2; Render.ShowArgCount && n > 0 {
+		noun := "fields"
+		if n == 1 {
+			noun = "field"
+		}
+		count := fmt.Sprintf(ArgCountFormat, n, noun)
+		if msg == "" {
+			count = strings.TrimPrefix(count, " ")
+		}
+		msg += count
+	}
+	msg = p.paint(p.message, msg)
+	switch {
+	case se.hideArgs:
+		// Args are for Attrs() only.
+	case msg == "":
+		msg = strings.TrimPrefix(argsString(args, p), Render.LeadingSpace)
+	default:
+		msg += argsString(args, p)
 	}
+	if missing := se.missingArgs(); len(missing) > 0 {
+		msg += fmt.Sprintf(MissingFormat, strings.Join(missing, ", "))
+	}
+	for _, op := range se.ops {
+		if msg == "" {
+			msg = op
+			continue
+		}
+		msg = op + ": " + msg
+	}
+	return msg
+}
 
-	if self := se.selfError(); self != "" {
-		s = fmt.Sprintf("%s%s; %s",
-			se.error.Error(),
-			se.argsString(),
-			self,
-		)
+// missingArgs returns the keys declared with .ValidArgs() that the error has
+// no arg for, if RequireValidArgs is true.
+func (se *sError) missingArgs() (missing []string) {
+	var keys []string
+	if !RequireValidArgs {
+		goto end
+	}
+	for _, key := range argKeys(se.args) {
+		keys = append(keys, se.unprefixKey(key))
+	}
+	for _, key := range se.validArgs {
+		if !slices.Contains(keys, key) {
+			missing = append(missing, key)
+		}
 	}
-	s = se.error.Error() + se.argsString()
 end:
-	return s
+	return missing
 }
 
 func (se *sError) ValidArgs(args ...string) SError {
@@ -87,22 +550,125 @@ func (se *sError) ValidArgs(args ...string) SError {
 	return se
 }
 
+// ValidArgsTyped works like .ValidArgs() with the keys of spec, but also makes
+// .Args() panic unless each value's reflect.Kind matches the kind spec declares
+// for its key. Values attached with .LazyArg() are not checked, and a nil value
+// matches only kinds that can be nil.
+func (se *sError) ValidArgsTyped(spec map[string]reflect.Kind) SError {
+	keys := make([]string, 0, len(spec))
+	for key := range spec {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	se.ValidArgs(keys...)
+	se.strict = true
+	se.argKinds = spec
+	return se
+}
+
 func (se *sError) NoArgs() SError {
 	return se
 }
 
 func (se *sError) Args(args ...any) SError {
-	se.chkArgs(len(args))
-	se.args = args
+	args = se.chkNewArgs(args)
+	se.args = se.prefixArgs(args)
 	return se.CloneWrap()
 }
 
+// argsWithDefaults returns the error's args followed by the default args set
+// by SetDefaults() that it lacks. Defaults are added when the outermost error
+// is rendered rather than stored in its args, so that errors created before
+// SetDefaults() is called get them and the layers of a chain do not repeat
+// them.
+func (se *sError) argsWithDefaults() []any {
+	return withDefaults(se.args, defaultArgs)
+}
+
+// withDefaults returns args followed by each of the default args whose key
+// args does not have.
+func withDefaults(args, defaults []any) []any {
+	if len(defaults) == 0 {
+		return args
+	}
+	keys := argKeys(args)
+	args = slices.Clip(args)
+	for i := 0; i < len(defaults)-1; i += 2 {
+		if !slices.Contains(keys, fmt.Sprintf("%v", defaults[i])) {
+			args = append(args, defaults[i], defaults[i+1])
+		}
+	}
+	return args
+}
+
+// WithPrefix namespaces the keys of args subsequently attached with .Args() or
+// .LazyArg() as "prefix.key" so that they do not collide with the same keys
+// attached elsewhere in the chain. Keys declared with .ValidArgs() are given
+// without the prefix, and attached keys are validated and checked for
+// presence without it.
+func (se *sError) WithPrefix(prefix string) SError {
+	sErr := se.cloneWrap()
+	sErr.prefix = prefix
+	return sErr
+}
+
+// LazyArg attaches an arg whose value is computed by fn only when the arg is
+// first rendered by Error(), Attrs() or Attr(), so expensive values cost nothing
+// for errors that are never logged. fn is called at most once.
+func (se *sError) LazyArg(key string, fn func() any) SError {
+	return se.addArgs(key, &lazyArg{fn: fn})
+}
+
+// WithRequestID attaches id as an arg with the key RequestIDKey, which strict
+// errors accept without declaring it.
+func (se *sError) WithRequestID(id string) SError {
+	return se.annotate(RequestIDKey, id)
+}
+
+// RequestID returns the request ID attached by .WithRequestID() to this error
+// or to the nearest error in its chain.
+func (se *sError) RequestID() (id string, found bool) {
+	value, found := se.chainArg(RequestIDKey)
+	if found {
+		id, found = value.(string)
+	}
+	return id, found
+}
+
+// HideArgsInError keeps the error's args out of the message returned by
+// Error() while leaving them available to Attrs() and Attr() for logging.
+func (se *sError) HideArgsInError() SError {
+	sErr := se.cloneWrap()
+	sErr.hideArgs = true
+	return sErr
+}
+
+// Logged marks the error as already logged so that code further up the stack
+// can check .IsLogged() and avoid logging it again.
+func (se *sError) Logged() SError {
+	sErr := se.cloneWrap()
+	sErr.logged = true
+	return sErr
+}
+
+// IsLogged reports whether .Logged() was called on this error or on any error
+// in its chain.
+func (se *sError) IsLogged() (logged bool) {
+	se.walk(func(sErr *sError) bool {
+		logged = sErr.logged
+		return !logged
+	})
+	return logged
+}
+
 func (se *sError) GetArgs() []any {
 	return se.args
 }
 
 func (se *sError) Err(err error, args ...any) SError {
 	se.err = err
+	// se.err is no longer the duplicate that .CloneWrap() created, if it was.
+	se.cloneWrapped = false
 	if len(args) > 0 {
 		//goland:noinspection GoTypeAssertionOnErrors,GoAssignmentToReceiver
 		se = se.Args(args...).(*sError)
@@ -112,6 +678,13 @@ func (se *sError) Err(err error, args ...any) SError {
 
 // CloneWrap clones an *sError but replaces its .err property with itself.
 func (se *sError) CloneWrap() SError {
+	return se.cloneWrap()
+}
+
+// cloneWrap works like .CloneWrap() but returns the *sError so that methods
+// can change the clone they return rather than this error, which may be a
+// sentinel shared by other goroutines.
+func (se *sError) cloneWrap() *sError {
 	wrapped := se.Clone()
 	//goland:noinspection GoTypeAssertionOnErrors
 	sErr := wrapped.(*sError)
@@ -123,18 +696,317 @@ func (se *sError) CloneWrap() SError {
 // Clone shallow-clones an *sError object
 func (se *sError) Clone() SError {
 	return &sError{
-		error:     se.error,
-		err:       se.err,
-		args:      se.args,
-		validArgs: se.validArgs,
-		recurs:    se.recurs,
-		sealed:    se.sealed,
+		error:       se.error,
+		id:          se.id,
+		err:         se.err,
+		code:        se.code,
+		prefix:      se.prefix,
+		ops:         se.ops,
+		helpURL:     se.helpURL,
+		tags:        se.tags,
+		related:     se.related,
+		metricKeys:  se.metricKeys,
+		longMessage: se.longMessage,
+		onceKeys:    se.onceKeys,
+		safeArgs:    se.safeArgs,
+		httpStatus:  se.httpStatus,
+		retryable:   se.retryable,
+		retryAfter:  se.retryAfter,
+		level:       se.level,
+		args:        se.args,
+		validArgs:   se.validArgs,
+		argKinds:    se.argKinds,
+		sealed:      se.sealed,
+		strict:      se.strict,
+		cast:        se.cast,
+		hideArgs:    se.hideArgs,
+		logged:      se.logged,
 	}
 }
 
-func (se *sError) Is(err error) bool {
+// Is reports whether err is this error's underlying message error, or whether
+// err is an SError that matches this error:
+//
+//   - If err was derived from the same call to New() as this error, e.g. both
+//     were enriched from one sentinel with .Args(), Is reports true because
+//     they share the identity token New() assigned and every clone keeps.
+//
+//   - If err has a code, Is reports whether this error has the same code. This
+//     lets errors.Is() match any error in a chain by code alone, e.g.
+//     errors.Is(err, serr.New("not found").WithCode("NOT_FOUND")).
+//
+//   - Otherwise, if err declares valid args with .ValidArgs(), err is treated as
+//     a template: Is reports whether this error has the same message and carries
+//     every arg the template declares. If the template was derived with
+//     .Args(), e.g. tmpl.Args("status", 404), the values must also equal those
+//     it was derived with; the values of the declared template itself, which
+//     .Args() overwrites on every call, are not compared.
+//
+// Note the template match means errors.Is() no longer requires identity when
+// the target has valid args; use == to test identity in that case.
+func (se *sError) Is(err error) (is bool) {
+	var target *sError
+	var ok bool
 	//goland:noinspection GoDirectComparisonOfErrors
-	return se.error == err
+	if se.error == err {
+		is = true
+		goto end
+	}
+	//goland:noinspection GoTypeAssertionOnErrors
+	target, ok = err.(*sError)
+	if !ok {
+		goto end
+	}
+	if target.id != 0 && se.id == target.id {
+		is = true
+		goto end
+	}
+	if target.code != "" {
+		is = se.IsCode(target.code)
+		goto end
+	}
+	if len(target.validArgs) > 0 {
+		is = se.matchesTemplate(target)
+	}
+end:
+	return is
+}
+
+// matchesTemplate reports whether se has the same message as template and
+// carries every arg in template.validArgs. When template is a clone returned by
+// .Args() or similar the values must also be deeply equal to those template has
+// for them; a template that is not a clone is matched on keys only, since its
+// args are whatever the last call to its .Args() left there.
+func (se *sError) matchesTemplate(template *sError) (matches bool) {
+	if se.error.Error() != template.error.Error() {
+		goto end
+	}
+	for _, key := range template.validArgs {
+		value, found := se.arg(key)
+		if !found {
+			goto end
+		}
+		if !template.cloneWrapped {
+			continue
+		}
+		want, found := template.arg(key)
+		if found && !reflect.DeepEqual(value, want) {
+			goto end
+		}
+	}
+	matches = true
+end:
+	return matches
+}
+
+// WithCode sets a machine-readable code for the error, e.g. "NOT_FOUND".
+func (se *sError) WithCode(code string) SError {
+	sErr := se.cloneWrap()
+	sErr.code = code
+	return sErr
+}
+
+// Code returns the code set with .WithCode(), or "" if none was set.
+func (se *sError) Code() string {
+	return se.code
+}
+
+// IsCode reports whether this error was given the non-empty code passed.
+func (se *sError) IsCode(code string) bool {
+	return code != "" && se.code == code
+}
+
+// WithHelpURL sets the URL of documentation that explains how to remedy the
+// error.
+func (se *sError) WithHelpURL(url string) SError {
+	sErr := se.cloneWrap()
+	sErr.helpURL = url
+	return sErr
+}
+
+// HelpURL returns the URL set by .WithHelpURL() on this error or on the
+// nearest error in its chain.
+func (se *sError) HelpURL() (url string, found bool) {
+	se.walk(func(sErr *sError) bool {
+		url = sErr.helpURL
+		return url == ""
+	})
+	return url, url != ""
+}
+
+// SafeArgs returns a copy of this error that, like errors cloned from it,
+// drops a trailing arg that has no value rather than panic even when StrictArgs
+// is true, logging the first dropped arg with slog.Warn(). It lets library
+// code attach dynamically built args without risking a crash in the host
+// application.
+func (se *sError) SafeArgs() SError {
+	sErr := se.cloneWrap()
+	if sErr.safeArgs == nil {
+		sErr.safeArgs = &sync.Once{}
+	}
+	return sErr
+}
+
+// LongMessage sets a longer explanation of the error, e.g. its likely causes
+// and remedies, that only PrettyVerbose() renders so Error() stays short.
+func (se *sError) LongMessage(text string) SError {
+	sErr := se.cloneWrap()
+	sErr.longMessage = text
+	return sErr
+}
+
+// PrettyVerbose renders the error as Error() does followed by the text set with
+// .LongMessage() on this error and on each error in its chain, outermost
+// first, each on its own line.
+func (se *sError) PrettyVerbose() string {
+	sb := strings.Builder{}
+	sb.WriteString(se.Error())
+	se.walk(func(sErr *sError) bool {
+		if sErr.longMessage != "" {
+			sb.WriteByte('\n')
+			sb.WriteString(sErr.longMessage)
+		}
+		return true
+	})
+	return sb.String()
+}
+
+// Tag attaches a low-cardinality label, e.g. "service" or "kind", for use by
+// metrics. Tags are kept apart from args, which may have high cardinality, and
+// are not rendered by Error().
+func (se *sError) Tag(key, value string) SError {
+	sErr := se.cloneWrap()
+	sErr.tags = make(map[string]string, len(se.tags)+1)
+	maps.Copy(sErr.tags, se.tags)
+	sErr.tags[key] = value
+	return sErr
+}
+
+// Tags returns the tags attached with .Tag() to this error and to the errors
+// in its chain, with tags nearer the top of the chain taking precedence. It
+// returns nil if there are none.
+func (se *sError) Tags() (tags map[string]string) {
+	se.walk(func(sErr *sError) bool {
+		for key, value := range sErr.tags {
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			if _, ok := tags[key]; !ok {
+				tags[key] = value
+			}
+		}
+		return true
+	})
+	return tags
+}
+
+// WithRelated attaches err under label, e.g. "cause" or "context", for errors
+// with more than one related error. Related errors are not part of the chain:
+// Unwrap() still returns only the wrapped error, so errors.Is() and
+// errors.As() do not see them. Nil errors are ignored.
+func (se *sError) WithRelated(label string, err error) SError {
+	sErr := se.cloneWrap()
+	if err == nil {
+		goto end
+	}
+	sErr.related = maps.Clone(se.related)
+	if sErr.related == nil {
+		sErr.related = make(map[string]error, 1)
+	}
+	sErr.related[label] = err
+end:
+	return sErr
+}
+
+// Related returns the errors attached with .WithRelated() by label, or nil if
+// there are none.
+func (se *sError) Related() map[string]error {
+	return maps.Clone(se.related)
+}
+
+// WithRetryable marks whether the operation that failed may be retried.
+func (se *sError) WithRetryable(retryable bool) SError {
+	sErr := se.cloneWrap()
+	sErr.retryable = &retryable
+	return sErr
+}
+
+// Retryable returns the value set by .WithRetryable() on this error or on the
+// nearest error in its chain, or false if none was set.
+func (se *sError) Retryable() (retryable bool) {
+	se.walk(func(sErr *sError) bool {
+		if sErr.retryable == nil {
+			return true
+		}
+		retryable = *sErr.retryable
+		return false
+	})
+	return retryable
+}
+
+// WithRetryAfter sets how long to wait before retrying, e.g. for errors that
+// result from rate limiting.
+func (se *sError) WithRetryAfter(d time.Duration) SError {
+	sErr := se.cloneWrap()
+	sErr.retryAfter = &d
+	return sErr
+}
+
+// RetryAfter returns the duration set by .WithRetryAfter() on this error or on
+// the nearest error in its chain.
+func (se *sError) RetryAfter() (d time.Duration, found bool) {
+	se.walk(func(sErr *sError) bool {
+		if sErr.retryAfter == nil {
+			return true
+		}
+		d = *sErr.retryAfter
+		found = true
+		return false
+	})
+	return d, found
+}
+
+// WithLevel sets the slog.Level at which the error should be logged.
+func (se *sError) WithLevel(level slog.Level) SError {
+	sErr := se.cloneWrap()
+	sErr.level = &level
+	return sErr
+}
+
+// Level returns the level set by .WithLevel() on this error or on the nearest
+// error in its chain, or slog.LevelError if none was set.
+func (se *sError) Level() (level slog.Level) {
+	level = slog.LevelError
+	se.walk(func(sErr *sError) bool {
+		if sErr.level == nil {
+			return true
+		}
+		level = *sErr.level
+		return false
+	})
+	return level
+}
+
+// Classify returns the nearest code, retryability and level found walking the
+// chain from this error inward, each taken independently of the others.
+func (se *sError) Classify() Classification {
+	var code string
+	se.walk(func(sErr *sError) bool {
+		code = sErr.code
+		return code == ""
+	})
+	return Classification{
+		Code:      code,
+		Retryable: se.Retryable(),
+		Level:     se.Level(),
+	}
+}
+
+// As lets errors.As() find the error this error stands in for when it was
+// cast from a non-SError, e.g. by Cast() or Enrich(), since that error is not
+// itself returned by Unwrap().
+func (se *sError) As(target any) bool {
+	return se.cast && errors.As(se.error, target)
 }
 
 func (se *sError) Unwrap() (err error) {
@@ -198,58 +1070,378 @@ end:
 	return attr, found
 }
 
+// Attrs returns the error's args as slog.Attrs, or nil if it has no args. When
+// RequireValidArgs is true an attr listing missing required args is added. A
+// key that is not a string panics unless the error has .SafeArgs() or
+// StrictArgs is false, in which case it is formatted using %v as Error() does.
 func (se *sError) Attrs() (attrs []slog.Attr) {
-	numArgs := len(se.args)
+	args := se.argsWithDefaults()
+	numArgs := len(args)
+	if numArgs < 2 {
+		goto end
+	}
 	attrs = make([]slog.Attr, numArgs/2)
 	for i := range attrs {
-		key, ok := se.args[2*i].(string)
+		key, ok := args[2*i].(string)
+		if !ok && StrictArgs && se.safeArgs == nil {
+			panicf("Unexpected non-string error key: %v", args[2*i])
+		}
 		if !ok {
-			panicf("Unexpected non-string error key: %v", se.args[i])
+			key = fmt.Sprintf("%v", args[2*i])
 		}
 		if i > len(attrs) {
 			panicf("Incorrect number of args %d in serr.Serror, should be %d", len(attrs), numArgs/2)
 		}
-		attrs[i] = slog.Any(key, se.args[2*i+1])
+		attrs[i] = argAttr(key, argValue(args[2*i+1]))
+	}
+end:
+	if missing := se.missingArgs(); len(missing) > 0 {
+		attrs = append(attrs, slog.String(MissingKey, strings.Join(missing, ", ")))
 	}
 	return attrs
 }
 
-func Diff(s1, s2 string, n int) (_, _ string, start, end int) {
+// argAttr returns the slog.Attr for an arg. An SError value becomes a group of
+// its base message, with the key "msg", and the args across its chain, so that
+// handlers expand it rather than logging its rendered message as a string.
+func argAttr(key string, value any) slog.Attr {
+	//goland:noinspection GoTypeAssertionOnErrors
+	sErr, ok := value.(*sError)
+	if !ok {
+		return slog.Any(key, maskArg(value))
+	}
+	attrs := append([]slog.Attr{slog.String("msg", sErr.BaseMessage())}, sErr.AllAttrs()...)
+	return slog.Attr{Key: key, Value: slog.GroupValue(attrs...)}
+}
 
-	// Convert strings to local byte slices for immutability
-	b1 := []byte(s1)
-	b2 := []byte(s2)
+// compactMessage renders an SError-valued arg on one line.
+func compactMessage(sErr SError) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(sErr.Error(), "\n", "; ")), " ")
+}
 
-	// Scan from the beginning and look for the first runes that are not the same.
-	// Continue slicing each rune off of both strings until you find a pair that are
-	// different or that the byte slices are empty.
-	for len(b1) > 0 && len(b2) > 0 {
-		ch1, width1 := utf8.DecodeRune(b1)
-		ch2, width2 := utf8.DecodeRune(b2)
-		if ch1 != ch2 {
-			break
-		}
-		b1 = b1[width1:]
-		b2 = b2[width2:]
-		start++
-	}
+// Plain returns a standard library error whose message is this error's
+// rendered message, args included. The result does not wrap anything, so no
+// serr internals leak past an API boundary that returns it.
+func (se *sError) Plain() error {
+	return errors.New(se.Error())
+}
 
-	// If both byte slices are empty, the strings were the same and no need to
-	// continue.
-	if len(b1)+len(b2) == 0 {
-		s1 = ""
-		s2 = ""
-		goto end
-	}
+// Op records the name of the logical operation that was being performed when
+// the error occurred. Calling .Op() repeatedly builds a breadcrumb rendered by
+// Error() with the most recently added operation first.
+func (se *sError) Op(name string) SError {
+	sErr := se.cloneWrap()
+	sErr.ops = append(slices.Clip(se.ops), name)
+	return sErr
+}
 
-	// Now scan from the end and look for the last runes that are not the same.
-	// Continue slicing each rune off the end of both strings until you find a pair
+// Ops returns the operation names added by .Op() in the order they were added.
+func (se *sError) Ops() []string {
+	return se.ops
+}
+
+// FreezeMessage returns a copy of the error whose message is this error's
+// current rendered message, with its args, operation names and valid args
+// cleared so that nothing re-renders, including the note of missing args that
+// RequireValidArgs adds. The copy wraps the same error this error wraps.
+func (se *sError) FreezeMessage() SError {
+	//goland:noinspection GoTypeAssertionOnErrors
+	frozen := se.Clone().(*sError)
+	frozen.error = errors.New(se.innerMessage())
+	frozen.err = se.base().err
+	frozen.args = nil
+	frozen.ops = nil
+	frozen.validArgs = nil
+	frozen.argKinds = nil
+	frozen.strict = false
+	frozen.cast = false
+	return frozen
+}
+
+// ChainError renders the message and args of each logical layer of the chain
+// separated by ": ", outermost first unless Render.InnermostFirst is true.
+// Unlike Error(), which renders only this error, ChainError includes every
+// error this error wraps. Non-SError layers are rendered using their own Error()
+// method.
+func (se *sError) ChainError() string {
+	return se.renderChain(func(err error, msg string) string {
+		return msg
+	})
+}
+
+// TypedError works like ChainError but annotates each layer with its Go type,
+// e.g.
+//
+//	loading config (*serr.sError): open x: no such file or directory (*fs.PathError): ...
+func (se *sError) TypedError() string {
+	return se.renderChain(func(err error, msg string) string {
+		return fmt.Sprintf("%s (%T)", msg, err)
+	})
+}
+
+// renderChain renders each logical layer of the chain with render, joined in
+// the order set by Render.InnermostFirst.
+func (se *sError) renderChain(render func(err error, msg string) string) string {
+	layers := chain(se)
+	parts := make([]string, len(layers))
+	for i, err := range layers {
+		msg := wrappedMessage(err.Error())
+		//goland:noinspection GoTypeAssertionOnErrors
+		if sErr, ok := err.(*sError); ok {
+			msg = sErr.innerMessage()
+		}
+		if i == 0 {
+			msg = se.message()
+		}
+		parts[i] = render(err, msg)
+	}
+	if Render.InnermostFirst {
+		slices.Reverse(parts)
+	}
+	return strings.Join(parts, ": ")
+}
+
+// AsRecords returns one record per logical layer of the chain, outermost
+// first. Each record has a "message" key holding the layer's message without
+// args and, if the layer has args, an "args" key holding a map of them.
+// Non-SError layers are included with the message returned by their Error()
+// and, if they implement ArgsProvider, their args.
+func (se *sError) AsRecords() []map[string]any {
+	layers := chain(se)
+	records := make([]map[string]any, len(layers))
+	for i, err := range layers {
+		record := map[string]any{"message": wrappedMessage(err.Error())}
+		//goland:noinspection GoTypeAssertionOnErrors
+		if sErr, ok := err.(*sError); ok {
+			record["message"] = sErr.BaseMessage()
+		}
+		args := layerArgs(err)
+		if i == 0 {
+			args = se.argsWithDefaults()
+		}
+		if len(args) > 0 {
+			m := argsAnyMap(args)
+			for key, value := range m {
+				m[key] = maskArg(value)
+			}
+			record["args"] = m
+		}
+		records[i] = record
+	}
+	return records
+}
+
+// ArgKeys returns the unique keys of the args attached to this error, sorted
+// in ascending order.
+func (se *sError) ArgKeys() []string {
+	return argKeys(se.args)
+}
+
+// AllArgKeys returns the unique keys of the args attached across the error's
+// chain, including those of any ArgsProvider, sorted in ascending order.
+func (se *sError) AllArgKeys() []string {
+	var args []any
+	for _, err := range chain(se) {
+		args = append(args, layerArgs(err)...)
+	}
+	return argKeys(args)
+}
+
+// Fingerprint returns a stable identifier for the kind of error, for grouping
+// errors in an aggregator: a hex-encoded hash of the base message of each layer
+// of the chain and of the arg keys found across it. Arg values, which usually
+// vary between occurrences, are ignored unless FingerprintValues is true.
+func (se *sError) Fingerprint() string {
+	h := sha256.New()
+	for _, msg := range se.BaseMessages() {
+		_, _ = fmt.Fprintf(h, "%q\n", msg)
+	}
+	if !FingerprintValues {
+		for _, key := range se.AllArgKeys() {
+			_, _ = fmt.Fprintf(h, "%q\n", key)
+		}
+		goto end
+	}
+	for _, attr := range se.AllAttrs() {
+		_, _ = fmt.Fprintf(h, "%q=%q\n", attr.Key, attr.Value.String())
+	}
+end:
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// Summary returns a terse one-line summary of the error for alerts: its base
+// message followed by the args in its chain whose keys are in SummaryKeys, in
+// that order, excerpted to SummaryWidth runes.
+func (se *sError) Summary() string {
+	var args []any
+	for _, key := range SummaryKeys {
+		if value, found := se.chainArg(key); found {
+			args = append(args, key, value)
+		}
+	}
+	s := se.BaseMessage()
+	if len(args) > 0 {
+		s += " " + RenderArgs(args)
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	return Excerpt(s, SummaryWidth)
+}
+
+// AsRecord returns a slog.Record for the current time with the given level and
+// message, holding the args found across err's chain as returned by
+// .AllAttrs(), ready to pass to a slog.Handler. If msg is empty err's rendered
+// message from Error() is used. A nil err yields a record with no attrs.
+//
+//goland:noinspection GoUnusedExportedFunction
+func AsRecord(err error, level slog.Level, msg string) slog.Record {
+	var record slog.Record
+	if err == nil {
+		record = slog.NewRecord(time.Now(), level, msg, 0)
+		goto end
+	}
+	if msg == "" {
+		msg = err.Error()
+	}
+	record = slog.NewRecord(time.Now(), level, msg, 0)
+	record.AddAttrs(standIn(err).AllAttrs()...)
+end:
+	return record
+}
+
+// RenderWith renders err exactly as the application's slog handler would log
+// it: a record with err's base message, .Level() and .AllAttrs() is passed to
+// the handler newHandler returns for a buffer, and the buffer is returned
+// without its trailing newline. A handler constructor is taken rather than a
+// handler because a handler's output cannot be redirected once built:
+//
+//	s, err := serr.RenderWith(sErr, func(w io.Writer) slog.Handler {
+//		return slog.NewJSONHandler(w, nil)
+//	})
+//
+// The record has no time, so the built-in handlers omit it. A nil err renders
+// as "".
+//
+//goland:noinspection GoUnusedExportedFunction
+func RenderWith(err error, newHandler func(w io.Writer) slog.Handler) (s string, handleErr error) {
+	var buf bytes.Buffer
+	var se *sError
+	var record slog.Record
+	if err == nil {
+		goto end
+	}
+	se = standIn(err)
+	record = slog.NewRecord(time.Time{}, se.Level(), se.BaseMessage(), 0)
+	record.AddAttrs(se.AllAttrs()...)
+	handleErr = newHandler(&buf).Handle(context.Background(), record)
+	if handleErr != nil {
+		goto end
+	}
+	s = strings.TrimSuffix(buf.String(), "\n")
+end:
+	return s, handleErr
+}
+
+// AllAttrs returns the args attached across the error's chain as slog.Attrs,
+// outermost first, including those of any ArgsProvider. When a key appears in
+// more than one layer the outermost value is kept unless InnerAttrsOverride is
+// true, in which case the innermost value replaces it in place.
+func (se *sError) AllAttrs() (attrs []slog.Attr) {
+	index := make(map[string]int)
+	for _, err := range chain(se) {
+		args := layerArgs(err)
+		for i := 0; i < len(args)-1; i += 2 {
+			attr := argAttr(fmt.Sprintf("%v", args[i]), argValue(args[i+1]))
+			j, found := index[attr.Key]
+			switch {
+			case !found:
+				index[attr.Key] = len(attrs)
+				attrs = append(attrs, attr)
+			case InnerAttrsOverride:
+				attrs[j] = attr
+			}
+		}
+	}
+	for i := 0; i < len(defaultArgs)-1; i += 2 {
+		key := fmt.Sprintf("%v", defaultArgs[i])
+		if _, found := index[key]; !found {
+			attrs = append(attrs, argAttr(key, argValue(defaultArgs[i+1])))
+		}
+	}
+	return attrs
+}
+
+// ChainDepth returns the number of logical layers in the error's chain,
+// including this error and not counting the duplicates .CloneWrap() creates.
+func (se *sError) ChainDepth() int {
+	return len(chain(se))
+}
+
+// ArgCount returns the number of key-value pairs attached to this error.
+func (se *sError) ArgCount() int {
+	return len(se.argsWithDefaults()) / 2
+}
+
+// TotalArgCount returns the number of key-value pairs attached across the
+// error's chain, counting each logical layer once and including the args of
+// any ArgsProvider in the chain.
+func (se *sError) TotalArgCount() (count int) {
+	count = se.ArgCount()
+	for _, err := range chain(se)[1:] {
+		count += len(layerArgs(err)) / 2
+	}
+	return count
+}
+
+func Diff(s1, s2 string, n int) (_, _ string, start, end int) {
+	return diff(s1, s2, n, func(r1, r2 rune) bool {
+		return r1 == r2
+	})
+}
+
+// DiffFold works like Diff but compares runes case-insensitively using Unicode
+// simple case folding. The returned excerpts retain the original casing.
+//
+//goland:noinspection GoUnusedExportedFunction
+func DiffFold(s1, s2 string, n int) (_, _ string, start, end int) {
+	return diff(s1, s2, n, equalFold)
+}
+
+func diff(s1, s2 string, n int, equal func(r1, r2 rune) bool) (_, _ string, start, end int) {
+
+	// Convert strings to local byte slices for immutability
+	b1 := []byte(s1)
+	b2 := []byte(s2)
+
+	// Scan from the beginning and look for the first runes that are not the same.
+	// Continue slicing each rune off of both strings until you find a pair that are
+	// different or that the byte slices are empty.
+	for len(b1) > 0 && len(b2) > 0 {
+		ch1, width1 := utf8.DecodeRune(b1)
+		ch2, width2 := utf8.DecodeRune(b2)
+		if !equal(ch1, ch2) {
+			break
+		}
+		b1 = b1[width1:]
+		b2 = b2[width2:]
+		start++
+	}
+
+	// If both byte slices are empty, the strings were the same and no need to
+	// continue.
+	if len(b1)+len(b2) == 0 {
+		s1 = ""
+		s2 = ""
+		goto end
+	}
+
+	// Now scan from the end and look for the last runes that are not the same.
+	// Continue slicing each rune off the end of both strings until you find a pair
 	// that are different or that the byte slices are empty.
 	// If you get to within `n` of each end, stop.
 	for len(b1) > 0 && len(b2) > 0 {
 		ch1, width1 := utf8.DecodeLastRune(b1)
 		ch2, width2 := utf8.DecodeLastRune(b2)
-		if ch1 != ch2 {
+		if !equal(ch1, ch2) {
 			break
 		}
 		b1 = b1[:len(b1)-width1]
@@ -269,14 +1461,30 @@ end:
 	return s1, s2, start, end
 }
 
+// Excerpt returns s unchanged if it is at most width runes long, otherwise the
+// start and end of s with EllipsisRune between them, width runes in all.
+//
+// The ellipsis is always shown when s is cut, so that the truncation is never
+// hidden, even when that leaves little or no room for s itself:
+//
+//   - width 1 returns just the ellipsis, e.g. "…".
+//   - width 2 returns the first rune and the ellipsis, e.g. "A…".
+//   - width 3 returns the first and last runes around it, e.g. "A…Z".
+//
+// A width less than 1 leaves no room for s, but the ellipsis is still returned
+// so that the truncation is not hidden.
 func Excerpt(s string, width int) string {
 	var prefix, suffix int
 
 	cnt := utf8.RuneCountInString(s)
-	if cnt <= width {
+	if cnt <= max(width, 0) {
 		// String is shorter than allocated width. Clearly, there is no need to excerpt.
 		goto end
 	}
+	if width < 1 {
+		s = EllipsisRune
+		goto end
+	}
 
 	// Start with half of the allocated width
 	prefix = width / 2
@@ -300,36 +1508,503 @@ end:
 	return s
 }
 
-func (se *sError) argsString() string {
+func argsString(args []any, p palette) string {
+	if Render.ArgsFormat == JSONArgs {
+		return argsJSON(args)
+	}
 	sb := strings.Builder{}
-	for i := 0; i < len(se.args)-1; i += 2 {
-		sb.WriteString(" [")
-		sb.WriteString(fmt.Sprintf("%v", se.args[i]))
-		sb.WriteByte('=')
-		switch value := se.args[i+1].(type) {
+	for i := 0; i < len(args)-1; i += 2 {
+		key := fmt.Sprintf("%v", args[i])
+		if i == 0 {
+			sb.WriteString(Render.LeadingSpace)
+		} else {
+			sb.WriteString(Render.ArgSpacing)
+		}
+		sb.WriteString(Render.ArgOpen)
+		sb.WriteString(p.paint(p.key, key))
+		sb.WriteString(Render.ArgSeparator)
+		switch value := argValue(args[i+1]).(type) {
 		case string:
 			sb.WriteByte('\'')
-			sb.WriteString(value)
+			sb.WriteString(Render.capValue(key, maskValue(value)))
 			sb.WriteByte('\'')
+		case SError:
+			sb.WriteString(Render.capValue(key, maskValue(compactMessage(value))))
 		default:
-			sb.WriteString(fmt.Sprintf("%v", value))
+			sb.WriteString(Render.capValue(key, maskValue(fmt.Sprintf("%v", value))))
 		}
-		sb.WriteString("]")
+		sb.WriteString(Render.ArgClose)
 	}
 	return sb.String()
 }
 
-func (se *sError) chkArgs(count int) {
-	if count%2 != 0 {
+// RenderArgs renders args as Error() renders the args of an SError, using the
+// format in Render but without its leading space, e.g. "[id=1] [table='users']",
+// so that tooling can render context consistently without creating an SError.
+// A trailing key without a value is ignored.
+//
+//goland:noinspection GoUnusedExportedFunction
+func RenderArgs(args []any) string {
+	return strings.TrimPrefix(argsString(args, palette{}), Render.LeadingSpace)
+}
+
+// RenderArgsMap works like RenderArgs for args held in a map, rendering them
+// sorted by key.
+//
+//goland:noinspection GoUnusedExportedFunction
+func RenderArgsMap(m map[string]any) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	args := make([]any, 0, 2*len(m))
+	for _, key := range keys {
+		args = append(args, key, m[key])
+	}
+	return RenderArgs(args)
+}
+
+// argsJSON renders args as a JSON object with sorted keys, preceded by
+// Render.LeadingSpace.
+// Values that cannot be encoded as JSON are rendered as strings using %v.
+func argsJSON(args []any) (s string) {
+	var b []byte
+	var err error
+	if len(args) < 2 {
+		goto end
+	}
+	b, err = json.Marshal(argsMap(args))
+	if err != nil {
+		goto end
+	}
+	s = Render.LeadingSpace + string(b)
+end:
+	return s
+}
+
+// argsMap returns args as a map of JSON-encoded values keyed by the args' keys.
+func argsMap(args []any) map[string]json.RawMessage {
+	m := make(map[string]json.RawMessage, len(args)/2)
+	for i := 0; i < len(args)-1; i += 2 {
+		key := fmt.Sprintf("%v", args[i])
+		value := argValue(args[i+1])
+		if s, ok := value.(string); ok {
+			value = Render.capValue(key, maskValue(s))
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			b, _ = json.Marshal(fmt.Sprintf("%v", value))
+		}
+		m[key] = b
+	}
+	return m
+}
+
+// errorJSON is the shape MarshalJSON() encodes each layer of a chain as.
+type errorJSON struct {
+	Message string                     `json:"message"`
+	Code    string                     `json:"code,omitempty"`
+	Args    map[string]json.RawMessage `json:"args,omitempty"`
+	Wrapped *errorJSON                 `json:"wrapped,omitempty"`
+}
+
+// MarshalJSON encodes the error's chain as nested objects, outermost first,
+// each with its base message, its code if set, its args and the layer it
+// wraps, e.g.
+//
+//	{"message":"lookup failed","args":{"id":1},"wrapped":{"message":"timeout"}}
+//
+// Arg values keep their JSON types: numbers and bools are encoded natively,
+// values implementing json.Marshaler encode themselves, and values that cannot
+// be encoded are encoded as strings using %v. Masks registered with
+// RegisterMask() apply to string values.
+func (se *sError) MarshalJSON() ([]byte, error) {
+	var root, last *errorJSON
+	for _, err := range chain(se) {
+		layer := &errorJSON{Message: wrappedMessage(layerMessage(err))}
+		//goland:noinspection GoTypeAssertionOnErrors
+		if sErr, ok := err.(*sError); ok {
+			layer.Message = sErr.BaseMessage()
+			layer.Code = sErr.code
+		}
+		args := layerArgs(err)
+		if root == nil {
+			args = se.argsWithDefaults()
+		}
+		if len(args) > 1 {
+			layer.Args = jsonArgs(args)
+		}
+		if root == nil {
+			root = layer
+		} else {
+			last.Wrapped = layer
+		}
+		last = layer
+	}
+	return json.Marshal(root)
+}
+
+// jsonArgs encodes each arg value as JSON keyed by the arg's key.
+func jsonArgs(args []any) map[string]json.RawMessage {
+	m := make(map[string]json.RawMessage, len(args)/2)
+	for i := 0; i < len(args)-1; i += 2 {
+		value := maskArg(argValue(args[i+1]))
+		b, err := json.Marshal(value)
+		if err != nil {
+			b, _ = json.Marshal(fmt.Sprintf("%v", value))
+		}
+		m[fmt.Sprintf("%v", args[i])] = b
+	}
+	return m
+}
+
+// argsAnyMap returns args as a map keyed by the args' keys.
+func argsAnyMap(args []any) map[string]any {
+	m := make(map[string]any, len(args)/2)
+	for i := 0; i < len(args)-1; i += 2 {
+		m[fmt.Sprintf("%v", args[i])] = argValue(args[i+1])
+	}
+	return m
+}
+
+// argKeys returns the sorted unique keys of args.
+func argKeys(args []any) []string {
+	keys := make([]string, 0, len(args)/2)
+	for i := 0; i < len(args)-1; i += 2 {
+		keys = append(keys, fmt.Sprintf("%v", args[i]))
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// layerArgs returns the args carried by a single layer of a chain, whether it
+// is an SError or an ArgsProvider.
+func layerArgs(err error) (args []any) {
+	//goland:noinspection GoTypeAssertionOnErrors
+	switch e := err.(type) {
+	case *sError:
+		args = e.args
+	case ArgsProvider:
+		args = e.ErrorArgs()
+	}
+	return args
+}
+
+// arg returns the value of the arg with the given key attached to this error.
+func (se *sError) arg(key string) (value any, found bool) {
+	for i := 0; i < len(se.args)-1; i += 2 {
+		if se.args[i] != key {
+			continue
+		}
+		value = argValue(se.args[i+1])
+		found = true
+		goto end
+	}
+end:
+	return value, found
+}
+
+// RangeArgs calls fn for each key-value pair attached to this error, in the
+// order attached, stopping when fn returns false. Unlike Attrs() it builds no
+// slice or map, for custom rendering.
+func (se *sError) RangeArgs(fn func(key string, value any) bool) {
+	for i := 0; i < len(se.args)-1; i += 2 {
+		key, ok := se.args[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", se.args[i])
+		}
+		if !fn(key, argValue(se.args[i+1])) {
+			break
+		}
+	}
+}
+
+// FindArg returns the first arg in the error's chain, outermost layer first,
+// for which pred returns true, e.g. the first arg whose value is a negative
+// int. Args of any ArgsProvider in the chain are included.
+func (se *sError) FindArg(pred func(key string, value any) bool) (key string, value any, found bool) {
+	eachLayer(se, func(err error) bool {
+		args := layerArgs(err)
+		for i := 0; i < len(args)-1; i += 2 {
+			k := fmt.Sprintf("%v", args[i])
+			v := argValue(args[i+1])
+			if pred(k, v) {
+				key, value, found = k, v, true
+				break
+			}
+		}
+		return !found
+	})
+	return key, value, found
+}
+
+// MetricArgs attaches args as .Args() does but also marks their keys as safe
+// to use as metric labels, for values with low cardinality such as a status or
+// region rather than a user ID.
+func (se *sError) MetricArgs(args ...any) SError {
+	args = se.chkNewArgs(args)
+	args = se.prefixArgs(args)
+	sErr := se.cloneWrap()
+	sErr.args = append(slices.Clip(se.args), args...)
+	sErr.metricKeys = append(slices.Clip(se.metricKeys), argKeys(args)...)
+	return sErr
+}
+
+// MetricLabels returns the args in the error's chain that are safe to use as
+// metric labels, i.e. those attached with .MetricArgs() or whose keys are in
+// MetricSafeKeys, with values formatted using %v. When a key appears in more
+// than one layer the outermost value is kept. It returns nil if there are none.
+func (se *sError) MetricLabels() (labels map[string]string) {
+	se.walk(func(sErr *sError) bool {
+		for i := 0; i < len(sErr.args)-1; i += 2 {
+			key := fmt.Sprintf("%v", sErr.args[i])
+			if !slices.Contains(sErr.metricKeys, key) && !slices.Contains(MetricSafeKeys, key) {
+				continue
+			}
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			if _, ok := labels[key]; !ok {
+				labels[key] = fmt.Sprintf("%v", argValue(sErr.args[i+1]))
+			}
+		}
+		return true
+	})
+	return labels
+}
+
+// chainArg returns the value of the arg with the given key attached to the
+// outermost error in the chain that has it.
+func (se *sError) chainArg(key string) (value any, found bool) {
+	se.walk(func(sErr *sError) bool {
+		value, found = sErr.arg(key)
+		return !found
+	})
+	return value, found
+}
+
+// SetArg returns a clone of this error in which the value of the arg with the
+// given key is replaced, or in which it is attached as a new arg if there is
+// none. If the key was attached more than once only its first occurrence is
+// updated, matching the value that .Attr() returns. This error is unchanged.
+func (se *sError) SetArg(key string, value any) SError {
+	//goland:noinspection GoTypeAssertionOnErrors
+	sErr := se.Clone().(*sError)
+	sErr.cloneWrapped = se.cloneWrapped
+	sErr.chkNewArgs([]any{key, value})
+	key = se.prefixKey(key)
+	for i := 0; i < len(sErr.args)-1; i += 2 {
+		if sErr.args[i] != key {
+			continue
+		}
+		sErr.args = slices.Clone(sErr.args)
+		sErr.args[i+1] = value
+		return sErr
+	}
+	sErr.args = append(slices.Clip(sErr.args), key, value)
+	return sErr
+}
+
+// SnapshotArgs captures the args attached to this error and returns a function
+// that returns a clone of this error with those args restored, discarding any
+// args attached with .Args() in between, e.g. to revert temporary debug args.
+// This error is unchanged. The function may be called more than once.
+func (se *sError) SnapshotArgs() func() SError {
+	snapshot := slices.Clone(se.args)
+	return func() SError {
+		//goland:noinspection GoTypeAssertionOnErrors
+		sErr := se.Clone().(*sError)
+		sErr.cloneWrapped = se.cloneWrapped
+		sErr.args = slices.Clone(snapshot)
+		return sErr
+	}
+}
+
+// addArgs returns a clone of this error with args attached, namespaced by
+// .WithPrefix(), in addition to those already attached. This error is
+// unchanged.
+func (se *sError) addArgs(args ...any) SError {
+	args = se.chkNewArgs(args)
+	sErr := se.cloneWrap()
+	sErr.args = append(slices.Clip(se.args), se.prefixArgs(args)...)
+	return sErr
+}
+
+// annotate works like addArgs but accepts keys that a strict error, e.g. one
+// created by NewSentinel(), does not declare with .ValidArgs(), for the args
+// serr attaches itself under conventional keys such as RequestIDKey and for
+// the context Enrich() attaches.
+func (se *sError) annotate(args ...any) SError {
+	args = se.chkArgs(args)
+	if se.argKinds != nil {
+		se.chkArgKinds(args)
+	}
+	sErr := se.cloneWrap()
+	sErr.args = append(slices.Clip(se.args), args...)
+	return sErr
+}
+
+// prefixArgs returns args with each string key namespaced by .WithPrefix().
+func (se *sError) prefixArgs(args []any) []any {
+	if se.prefix == "" {
+		return args
+	}
+	prefixed := slices.Clone(args)
+	for i := 0; i < len(prefixed)-1; i += 2 {
+		if key, ok := prefixed[i].(string); ok {
+			prefixed[i] = se.prefixKey(key)
+		}
+	}
+	return prefixed
+}
+
+func (se *sError) prefixKey(key string) string {
+	if se.prefix == "" {
+		return key
+	}
+	return se.prefix + "." + key
+}
+
+// unprefixKey returns key without the namespace .WithPrefix() added, for
+// checking it against the keys declared with .ValidArgs(), which are always
+// unprefixed.
+func (se *sError) unprefixKey(key string) string {
+	if se.prefix == "" {
+		return key
+	}
+	return strings.TrimPrefix(key, se.prefix+".")
+}
+
+// chkNewArgs validates args about to be attached, returning them with any
+// trailing arg that lacks a value dropped when StrictArgs is false.
+func (se *sError) chkNewArgs(args []any) []any {
+	args = se.chkArgs(args)
+	if se.strict {
+		se.chkValidArgs(args)
+	}
+	if se.argKinds != nil {
+		se.chkArgKinds(args)
+	}
+	return args
+}
+
+// lazyArg is an arg value that is computed only when the arg is rendered.
+type lazyArg struct {
+	once  sync.Once
+	fn    func() any
+	value any
+}
+
+// argValue returns value, or the value computed by value if it is a *lazyArg.
+func argValue(value any) any {
+	lazy, ok := value.(*lazyArg)
+	if !ok {
+		goto end
+	}
+	lazy.once.Do(func() {
+		lazy.value = lazy.fn()
+	})
+	value = lazy.value
+end:
+	return value
+}
+
+func (se *sError) chkArgs(args []any) []any {
+	count := len(args)
+	if count%2 == 0 {
+		goto end
+	}
+	switch {
+	case se.safeArgs != nil:
+		se.safeArgs.Do(func() {
+			se.warnDroppedArg(args[count-1])
+		})
+	case StrictArgs:
 		panicf("SError.Args() for '%s' must receive key-value pairs for args; received %d args instead",
 			se.error.Error(), count)
+	default:
+		se.warnDroppedArg(args[count-1])
+	}
+	args = args[:count-1]
+end:
+	return args
+}
+
+func (se *sError) warnDroppedArg(arg any) {
+	slog.Warn("serr: dropping trailing arg that has no value",
+		"error", se.error.Error(),
+		"arg", arg,
+	)
+}
+
+// equalFold reports whether r1 and r2 are equal under Unicode simple case
+// folding.
+func equalFold(r1, r2 rune) (equal bool) {
+	if r1 == r2 {
+		equal = true
+		goto end
+	}
+	for r := unicode.SimpleFold(r1); r != r1; r = unicode.SimpleFold(r) {
+		if r == r2 {
+			equal = true
+			goto end
+		}
+	}
+end:
+	return equal
+}
+
+func (se *sError) chkValidArgs(args []any) {
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if ok && slices.Contains(se.validArgs, key) {
+			continue
+		}
+		panicf("SError.Args() for '%s' received invalid arg key '%v'; valid keys are: %s",
+			se.error.Error(), args[i], strings.Join(se.validArgs, ", "))
+	}
+}
+
+func (se *sError) chkArgKinds(args []any) {
+	for i := 0; i < len(args)-1; i += 2 {
+		key, _ := args[i].(string)
+		want, ok := se.argKinds[key]
+		if !ok {
+			continue
+		}
+		if _, ok = args[i+1].(*lazyArg); ok {
+			continue
+		}
+		if kindMatches(args[i+1], want) {
+			continue
+		}
+		panicf("SError.Args() for '%s' received arg '%s' of kind %s; expected %s",
+			se.error.Error(), key, reflect.ValueOf(args[i+1]).Kind(), want)
+	}
+}
+
+// kindMatches reports whether value has the given kind, treating a nil value as
+// matching any kind that can be nil.
+func kindMatches(value any, kind reflect.Kind) (matches bool) {
+	if value != nil {
+		matches = reflect.ValueOf(value).Kind() == kind
+		goto end
 	}
+	switch kind {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		matches = true
+	}
+end:
+	return matches
 }
 
+// prefixRunes returns the first n runes of input. It counts runes rather than
+// byte offsets so that multibyte input is not cut short.
 func prefixRunes(input string, n int) string {
 	result := make([]rune, 0)
-	for i, r := range input {
-		if i >= n {
+	for _, r := range input {
+		if len(result) >= n {
 			break
 		}
 		result = append(result, r)
@@ -351,25 +2026,107 @@ func suffixRunes(input string, n int) string {
 	return string(result)
 }
 
-func (se *sError) recursing() (yes bool) {
-	for i := len(se.recurs) - 1; i >= 0; i-- {
-		//goland:noinspection GoDirectComparisonOfErrors
-		if se == se.recurs[i] {
-			yes = true
-			goto end
+// base returns the *sError that se was clone-wrapped from, descending through
+// every duplicate that .CloneWrap() created along the way.
+func (se *sError) base() *sError {
+	sErr := se
+	for sErr.cloneWrapped {
+		//goland:noinspection GoTypeAssertionOnErrors
+		next, ok := sErr.err.(*sError)
+		if !ok {
+			break
 		}
+		sErr = next
 	}
-end:
-	return yes
+	return sErr
 }
 
-func (se *sError) selfError() (s string) {
-	if !se.recursing() {
-		se.recurs = append(se.recurs, se)
-		s = se.err.Error()
-		se.recurs = se.recurs[:len(se.recurs)-1]
+// nextLayer returns the logical layer wrapped by err, skipping the duplicates
+// created by .CloneWrap(). Non-SError layers are unwrapped using
+// errors.Unwrap().
+func nextLayer(err error) error {
+	//goland:noinspection GoTypeAssertionOnErrors
+	if sErr, ok := err.(*sError); ok {
+		return sErr.base().err
+	}
+	return errors.Unwrap(err)
+}
+
+// eachLayer calls fn for each logical layer of err's chain, outermost first,
+// until fn returns false or the chain ends or cycles back on itself.
+func eachLayer(err error, fn func(error) bool) {
+	visited := make(map[error]struct{})
+	for ; err != nil; err = nextLayer(err) {
+		if !reflect.TypeOf(err).Comparable() {
+			// Uncomparable errors cannot be tracked, but also cannot be the
+			// pointer needed to form a cycle.
+			if !fn(err) {
+				break
+			}
+			continue
+		}
+		if _, seen := visited[err]; seen {
+			break
+		}
+		visited[err] = struct{}{}
+		if !fn(err) {
+			break
+		}
 	}
-	return s
+}
+
+// chain returns each logical layer of err's chain, outermost first.
+func chain(err error) (errs []error) {
+	eachLayer(err, func(err error) bool {
+		errs = append(errs, err)
+		return true
+	})
+	return errs
+}
+
+// walk calls fn for each logical SError layer of the chain, outermost first,
+// stopping when fn returns false. Non-SError layers, e.g. those created by
+// fmt.Errorf() with %w, are passed through using errors.Unwrap() so the SErrors
+// they wrap are still visited.
+func (se *sError) walk(fn func(*sError) bool) {
+	eachLayer(se, func(err error) bool {
+		//goland:noinspection GoTypeAssertionOnErrors
+		sErr, ok := err.(*sError)
+		return !ok || fn(sErr)
+	})
+}
+
+// Walk calls fn for this error and then for each SError it wraps, outermost
+// first, skipping the duplicates .CloneWrap() creates and passing through any
+// non-SError layers between them. Walking stops when fn returns false.
+func (se *sError) Walk(fn func(sErr SError) bool) {
+	se.WalkDepth(func(_ int, sErr SError) bool {
+		return fn(sErr)
+	})
+}
+
+// WalkDepth is like Walk but also passes fn the nesting level of each layer,
+// starting at 0 for this error, for rendering chains with indentation.
+func (se *sError) WalkDepth(fn func(depth int, sErr SError) bool) {
+	depth := 0
+	se.walk(func(sErr *sError) bool {
+		cont := fn(depth, sErr)
+		depth++
+		return cont
+	})
+}
+
+// HasCycle reports whether the error's chain wraps back around to an error
+// already in the chain, which would be a programming error. Unlike Error() it
+// renders nothing while checking.
+func (se *sError) HasCycle() (cycle bool) {
+	var last error
+	eachLayer(se, func(err error) bool {
+		last = err
+		return true
+	})
+	cycle = last != nil && nextLayer(last) != nil
+	return cycle
 }
 
 func panicf(msg string, args ...any) {
@@ -387,6 +2144,7 @@ func Cast(err error, args ...any) SError {
 	}
 	sErr = &sError{
 		error: err,
+		cast:  true,
 	}
 end:
 	if err != nil && len(args) > 0 {
@@ -395,8 +2153,16 @@ end:
 	return sErr
 }
 
+// Wrap returns an SError with msg and args that wraps err. Wrap returns nil if
+// err is nil, as Cast() does, so that it can be used directly on a result:
+//
+//	return serr.Wrap(doThing(), "doing thing")
+//
 //goland:noinspection GoUnusedExportedFunction
 func Wrap(err error, msg string, args ...any) SError {
+	if err == nil {
+		return nil
+	}
 	sErr := New(msg).Err(err)
 	if len(args) > 0 {
 		return sErr.Args(args...)
@@ -404,6 +2170,130 @@ func Wrap(err error, msg string, args ...any) SError {
 	return sErr
 }
 
+// WrapOnce wraps err with msg, as Wrap does, unless err's chain already has an
+// arg with the given key or was already wrapped by WrapOnce with that key, so
+// that recursive or retried code paths attach their context only once. The key
+// is recorded on the wrapping error without being rendered. When err is
+// already wrapped it is returned as an SError without another layer, and
+// WrapOnce returns nil if err is nil.
+//
+//	return serr.WrapOnce(err, "sync", "syncing account")
+//
+//goland:noinspection GoUnusedExportedFunction
+func WrapOnce(err error, key, msg string) (sErr SError) {
+	var found bool
+	if err == nil {
+		goto end
+	}
+	eachLayer(err, func(layer error) bool {
+		//goland:noinspection GoTypeAssertionOnErrors
+		if se, ok := layer.(*sError); ok && slices.Contains(se.onceKeys, key) {
+			found = true
+		}
+		if slices.Contains(argKeys(layerArgs(layer)), key) {
+			found = true
+		}
+		return !found
+	})
+	if !found {
+		//goland:noinspection GoTypeAssertionOnErrors
+		wrapped := Wrap(err, msg).(*sError)
+		wrapped.onceKeys = append(wrapped.onceKeys, key)
+		sErr = wrapped
+		goto end
+	}
+	sErr = standIn(err)
+end:
+	return sErr
+}
+
+// standIn returns err if it is an *sError, otherwise an *sError that stands in
+// for err without adding a layer: it renders err's message and unwraps to what
+// err unwraps to, so errors.Is() and errors.As() still reach the rest of its
+// chain.
+func standIn(err error) *sError {
+	//goland:noinspection GoTypeAssertionOnErrors
+	if sErr, ok := err.(*sError); ok {
+		return sErr
+	}
+	return &sError{
+		error: err,
+		err:   nextLayer(err),
+		cast:  true,
+	}
+}
+
+// WrapStd works like Wrap but returns a single-layer SError whose Unwrap()
+// returns err itself, with none of the duplicate layers .Err() and .Args()
+// create, so errors.Unwrap(), errors.Is(), errors.As() and third-party matchers
+// that inspect the direct cause see exactly what they would for
+// fmt.Errorf("msg: %w", err). Pass args here rather than with a later .Args(),
+// which adds a duplicate layer as usual. WrapStd returns nil if err is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WrapStd(err error, msg string, args ...any) SError {
+	if err == nil {
+		return nil
+	}
+	//goland:noinspection GoTypeAssertionOnErrors
+	sErr := New(msg).(*sError)
+	sErr.err = err
+	sErr.args = sErr.chkNewArgs(args)
+	return sErr
+}
+
+// WrapfArgs works like Wrap but formats its message from format and fmtArgs
+// using fmt.Sprintf(), keeping the formatting args separate from the key-value
+// pairs in kvArgs that are attached as structured args.
+//
+//	serr.WrapfArgs(err, "opening %s", []any{path}, "attempt", 3)
+//
+//goland:noinspection GoUnusedExportedFunction
+func WrapfArgs(err error, format string, fmtArgs []any, kvArgs ...any) SError {
+	return Wrap(err, fmt.Sprintf(format, fmtArgs...), kvArgs...)
+}
+
+// Annotate wraps *errp with msg and args in place if *errp is not nil. It is
+// designed to be deferred in functions with a named error return:
+//
+//	func load(path string) (err error) {
+//		defer serr.Annotate(&err, "loading", "path", path)
+//		...
+//	}
+//
+//goland:noinspection GoUnusedExportedFunction
+func Annotate(errp *error, msg string, args ...any) {
+	if errp == nil || *errp == nil {
+		return
+	}
+	*errp = Wrap(*errp, msg, args...)
+}
+
+// Enrich returns an SError with the same message as err plus args appended to
+// any args err already has, without adding a wrapping message. errors.Is()
+// still matches err against the result, and err itself is left unchanged.
+// As the args are context rather than the error's own, a strict error accepts
+// keys it does not declare with .ValidArgs(). Enrich returns nil if err is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Enrich(err error, args ...any) SError {
+	var sErr SError
+	var se *sError
+	var ok bool
+	if err == nil {
+		goto end
+	}
+	//goland:noinspection GoTypeAssertionOnErrors
+	se, ok = err.(*sError)
+	if ok {
+		sErr = se.cloneWrap().annotate(args...)
+		goto end
+	}
+	sErr = standIn(err).annotate(args...)
+end:
+	return sErr
+}
+
 //goland:noinspection GoUnusedExportedFunction
 func As(err error, sErr SError) {
 	errors.As(err, &sErr)
@@ -414,7 +2304,219 @@ func Is(err, sErr error) bool {
 	return errors.Is(err, sErr)
 }
 
+// Depth returns the number of logical layers in err's chain, as ChainDepth()
+// does for an SError, or 0 if err is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Depth(err error) int {
+	return len(chain(err))
+}
+
+// ValidateArgs checks that every arg key attached to err's outermost logical
+// layer is one of the keys declared with .ValidArgs(), e.g. by NewSentinel(),
+// returning an error that lists any that are not rather than panicking as
+// .Args() does for strict sentinels. Args set with SetDefaults() are not
+// checked. It returns nil if err declares no valid args.
+//
+//goland:noinspection GoUnusedExportedFunction
+func ValidateArgs(err SError) (invalid error) {
+	var keys []string
+	//goland:noinspection GoTypeAssertionOnErrors
+	se, ok := err.(*sError)
+	if !ok || len(se.validArgs) == 0 {
+		goto end
+	}
+	for sErr := se; ; {
+		for _, key := range argKeys(sErr.args) {
+			key = sErr.unprefixKey(key)
+			if slices.Contains(se.validArgs, key) || slices.Contains(keys, key) {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		next, ok := sErr.err.(*sError)
+		if !sErr.cloneWrapped || !ok {
+			break
+		}
+		sErr = next
+	}
+	if len(keys) == 0 {
+		goto end
+	}
+	invalid = New(fmt.Sprintf("'%s' has invalid arg keys: %s; valid keys are: %s",
+		se.error.Error(), strings.Join(keys, ", "), strings.Join(se.validArgs, ", ")))
+end:
+	return invalid
+}
+
+// Flatten returns a single SError, with no wrapped error, whose message joins
+// the message of each logical layer of err's chain with ": ", outermost first,
+// and which carries the args of every SError or ArgsProvider in the chain.
+// Layers that are not SErrors, e.g. those created by fmt.Errorf(), contribute
+// their own message with the message of the error they wrap trimmed from its
+// end. Flatten returns nil if err is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Flatten(err error) (sErr SError) {
+	var msgs []string
+	var args []any
+	if err == nil {
+		goto end
+	}
+	eachLayer(err, func(layer error) bool {
+		if msg := layerMessage(layer); msg != "" {
+			msgs = append(msgs, msg)
+		}
+		args = append(args, layerArgs(layer)...)
+		return true
+	})
+	sErr = &sError{
+		error: errors.New(strings.Join(msgs, ": ")),
+		id:    nextID.Add(1),
+		args:  args,
+	}
+end:
+	return sErr
+}
+
+// layerMessage returns the message err contributes to its chain, without the
+// message of the error it wraps.
+func layerMessage(err error) (msg string) {
+	var next error
+	//goland:noinspection GoTypeAssertionOnErrors
+	if sErr, ok := err.(*sError); ok {
+		msg = sErr.BaseMessage()
+		goto end
+	}
+	msg = err.Error()
+	next = nextLayer(err)
+	if next == nil {
+		goto end
+	}
+	msg = strings.TrimSuffix(msg, next.Error())
+	msg = strings.TrimRight(msg, ": ")
+end:
+	return msg
+}
+
+// Root returns the innermost logical layer of err's chain, i.e. its root
+// cause, or nil if err is nil. An error that wraps nothing is its own root.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Root(err error) (root error) {
+	eachLayer(err, func(layer error) bool {
+		root = layer
+		return true
+	})
+	return root
+}
+
+// SameRoot reports whether a and b have the same root cause, as returned by
+// Root(). Roots are the same if they are equal, or if both are SErrors that
+// share the identity token New() assigned, e.g. a sentinel and a copy of it
+// enriched with .Args(). SameRoot returns false if either is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func SameRoot(a, b error) (same bool) {
+	var sErrA, sErrB *sError
+	var okA, okB bool
+	rootA, rootB := Root(a), Root(b)
+	if rootA == nil || rootB == nil {
+		goto end
+	}
+	//goland:noinspection GoTypeAssertionOnErrors
+	sErrA, okA = rootA.(*sError)
+	//goland:noinspection GoTypeAssertionOnErrors
+	sErrB, okB = rootB.(*sError)
+	if okA && okB {
+		same = sErrA.id != 0 && sErrA.id == sErrB.id || sErrA.error == sErrB.error
+		goto end
+	}
+	if reflect.TypeOf(rootA).Comparable() && reflect.TypeOf(rootB).Comparable() {
+		//goland:noinspection GoDirectComparisonOfErrors
+		same = rootA == rootB
+	}
+end:
+	return same
+}
+
+// Equal reports whether a and b have the same message and arg keys in each
+// logical layer of their chains, with deeply equal arg values. Two nil errors
+// are equal.
+//
+//goland:noinspection GoUnusedExportedFunction
+func Equal(a, b SError) bool {
+	return EqualFunc(a, b, func(_ string, va, vb any) bool {
+		return reflect.DeepEqual(va, vb)
+	})
+}
+
+// EqualFunc works like Equal but compares arg values using cmp, which is passed
+// the key of the arg, so that callers can decide per key what counts as equal,
+// e.g. timestamps within a tolerance. Messages and keys are compared exactly.
+//
+//goland:noinspection GoUnusedExportedFunction
+func EqualFunc(a, b SError, cmp func(key string, va, vb any) bool) (equal bool) {
+	var chainA, chainB []error
+	if a == nil || b == nil {
+		equal = a == nil && b == nil
+		goto end
+	}
+	chainA, chainB = chain(a), chain(b)
+	if len(chainA) != len(chainB) {
+		goto end
+	}
+	for i := range chainA {
+		if layerMessage(chainA[i]) != layerMessage(chainB[i]) {
+			goto end
+		}
+		argsA := argsAnyMap(layerArgs(chainA[i]))
+		argsB := argsAnyMap(layerArgs(chainB[i]))
+		if len(argsA) != len(argsB) {
+			goto end
+		}
+		for key, va := range argsA {
+			vb, ok := argsB[key]
+			if !ok || !cmp(key, va, vb) {
+				goto end
+			}
+		}
+	}
+	equal = true
+end:
+	return equal
+}
+
+// IsType reports whether any error in err's chain is of type T, as errors.As()
+// would, without requiring a target variable:
+//
+//	if serr.IsType[*fs.PathError](err) { ... }
+//
+//goland:noinspection GoUnusedExportedFunction
+func IsType[T error](err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
+
 //goland:noinspection GoUnusedExportedFunction
 func ExcerptWithLen(s string, width int) string {
 	return fmt.Sprintf(LengthPrefixFormat, len(s), Excerpt(s, width))
 }
+
+// ExcerptWithLenMax works like ExcerptWithLen but treats maxWidth as the budget
+// for the entire result, reserving room for the length prefix before
+// excerpting s so the result is never longer than maxWidth runes.
+//
+//goland:noinspection GoUnusedExportedFunction
+func ExcerptWithLenMax(s string, maxWidth int) (result string) {
+	prefix := fmt.Sprintf(LengthPrefixFormat, len(s), "")
+	width := maxWidth - utf8.RuneCountInString(prefix)
+	if width < 1 {
+		// No room for any of s, so the prefix itself is all that fits.
+		result = prefixRunes(prefix, maxWidth)
+		goto end
+	}
+	result = prefix + Excerpt(s, width)
+end:
+	return result
+}
@@ -1,10 +1,19 @@
 package serr_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/mikeschinkel/go-serr"
@@ -34,6 +43,12 @@ func TestExcerpt(t *testing.T) {
 			want:   fmt.Sprintf("%s%s%s", "A", serr.EllipsisRune, "J"),
 			length: 3,
 		},
+		{
+			// The head counts runes, not bytes, so it is as wide as the tail.
+			source: "ééééééééé",
+			want:   fmt.Sprintf("%s%s%s", "éé", serr.EllipsisRune, "éé"),
+			length: 5,
+		},
 	}
 	for _, test := range tests {
 		t.Run(strconv.Itoa(test.length), func(t *testing.T) {
@@ -159,6 +174,14 @@ func TestDiff(t *testing.T) {
 			want2:   "XYZ",
 			n:       25,
 		},
+		{
+			name:    "Zero n",
+			source1: "abc",
+			source2: "abd",
+			want1:   serr.EllipsisRune,
+			want2:   serr.EllipsisRune,
+			n:       0,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -189,3 +212,2883 @@ func verifyDiffResult(t *testing.T, n int, source, want, got string) {
 		)
 	}
 }
+
+func TestRenderDelimiters(t *testing.T) {
+	var tests = []struct {
+		name   string
+		render serr.RenderConfig
+		want   string
+	}{
+		{
+			name:   "Default",
+			render: serr.DefaultRenderConfig,
+			want:   "failed [path='/tmp'] [count=3]",
+		},
+		{
+			name: "Braces and colon",
+			render: serr.RenderConfig{
+				ArgOpen:      "{",
+				ArgClose:     "}",
+				ArgSeparator: ":",
+				LeadingSpace: " ",
+				ArgSpacing:   " ",
+			},
+			want: "failed {path:'/tmp'} {count:3}",
+		},
+	}
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serr.Render = test.render
+			got := serr.New("failed").Args("path", "/tmp", "count", 3).Error()
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s",
+					test.want,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestArgCount(t *testing.T) {
+	inner := serr.New("inner").Args("a", 1, "b", 2)
+	var tests = []struct {
+		name      string
+		err       serr.SError
+		wantCount int
+		wantTotal int
+	}{
+		{
+			name:      "No args",
+			err:       serr.New("bare"),
+			wantCount: 0,
+			wantTotal: 0,
+		},
+		{
+			name:      "Single layer",
+			err:       inner,
+			wantCount: 2,
+			wantTotal: 2,
+		},
+		{
+			name:      "Two layers",
+			err:       serr.Wrap(inner, "outer", "c", 3),
+			wantCount: 1,
+			wantTotal: 3,
+		},
+		{
+			name:      "Three layers",
+			err:       serr.Wrap(serr.Wrap(inner, "middle", "d", 4, "e", 5), "outer", "f", 6),
+			wantCount: 1,
+			wantTotal: 5,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.ArgCount(); got != test.wantCount {
+				t.Errorf("ArgCount() mismatch\n\t\twant=%d\n\t\t got=%d", test.wantCount, got)
+			}
+			if got := test.err.TotalArgCount(); got != test.wantTotal {
+				t.Errorf("TotalArgCount() mismatch\n\t\twant=%d\n\t\t got=%d", test.wantTotal, got)
+			}
+		})
+	}
+}
+
+func TestDiffFold(t *testing.T) {
+	var tests = []struct {
+		name             string
+		source1, source2 string
+		want1, want2     string
+		n                int
+	}{
+		{
+			name:    "Differ only in case",
+			source1: "Hello World",
+			source2: "hELLO wORLD",
+			want1:   "",
+			want2:   "",
+			n:       25,
+		},
+		{
+			name:    "Mixed case with diff in middle",
+			source1: Xs[:10] + "Prefix ABC Suffix" + Xs[:10],
+			source2: Xs[:10] + "PREFIX xyz suffix" + Xs[:10],
+			want1:   "ABC",
+			want2:   "xyz",
+			n:       25,
+		},
+		{
+			name:    "Non-ASCII case folding",
+			source1: "ÉCOLE ÄBC",
+			source2: "école äxc",
+			want1:   "B",
+			want2:   "x",
+			n:       25,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got1, got2, _, _ := serr.DiffFold(test.source1, test.source2, test.n)
+			verifyDiffResult(t, 1, test.source1, test.want1, got1)
+			verifyDiffResult(t, 2, test.source2, test.want2, got2)
+		})
+	}
+}
+
+func TestNewSentinel(t *testing.T) {
+	var tests = []struct {
+		name      string
+		args      []any
+		wantPanic bool
+	}{
+		{
+			name: "Valid keys",
+			args: []any{"path", "/tmp", "mode", 0644},
+		},
+		{
+			name: "No args",
+		},
+		{
+			name:      "Invalid key",
+			args:      []any{"path", "/tmp", "size", 10},
+			wantPanic: true,
+		},
+		{
+			name:      "Non-string key",
+			args:      []any{1, "/tmp"},
+			wantPanic: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sentinel := serr.NewSentinel("open failed", "path", "mode")
+			panicked := didPanic(func() {
+				sentinel.Args(test.args...)
+			})
+			if panicked != test.wantPanic {
+				t.Errorf("Panic mismatch\n\t\twant=%t\n\t\t got=%t", test.wantPanic, panicked)
+			}
+		})
+	}
+}
+
+func didPanic(fn func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	fn()
+	return panicked
+}
+
+func TestRenderMaxValueLen(t *testing.T) {
+	var tests = []struct {
+		name   string
+		render serr.RenderConfig
+		want   string
+	}{
+		{
+			name:   "No limits",
+			render: serr.DefaultRenderConfig,
+			want:   "query failed [sql='SELECT * FROM users'] [body='ABCDEFGHIJ'] [id=1234567]",
+		},
+		{
+			name: "Global limit",
+			render: serr.RenderConfig{
+				ArgOpen:      "[",
+				ArgClose:     "]",
+				ArgSeparator: "=",
+				LeadingSpace: " ",
+				ArgSpacing:   " ",
+				MaxValueLen:  5,
+			},
+			want: fmt.Sprintf("query failed [sql='SE%srs'] [body='AB%sIJ'] [id=12%s67]",
+				serr.EllipsisRune, serr.EllipsisRune, serr.EllipsisRune),
+		},
+		{
+			name: "Per-key overrides",
+			render: serr.RenderConfig{
+				ArgOpen:      "[",
+				ArgClose:     "]",
+				ArgSeparator: "=",
+				LeadingSpace: " ",
+				ArgSpacing:   " ",
+				MaxValueLen:  5,
+				KeyMaxValueLen: map[string]int{
+					"sql":  0,
+					"body": 3,
+				},
+			},
+			want: fmt.Sprintf("query failed [sql='SELECT * FROM users'] [body='A%sJ'] [id=12%s67]",
+				serr.EllipsisRune, serr.EllipsisRune),
+		},
+	}
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serr.Render = test.render
+			got := serr.New("query failed").Args(
+				"sql", "SELECT * FROM users",
+				"body", "ABCDEFGHIJ",
+				"id", 1234567,
+			).Error()
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s",
+					test.want,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestIsCode(t *testing.T) {
+	notFound := serr.New("not found").WithCode("NOT_FOUND")
+	var tests = []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{
+			name:   "Same code, different message",
+			err:    serr.New("user missing").WithCode("NOT_FOUND"),
+			target: notFound,
+			want:   true,
+		},
+		{
+			name:   "Same code through wrap chain",
+			err:    serr.Wrap(serr.Wrap(serr.New("user missing").WithCode("NOT_FOUND"), "loading user"), "rendering profile", "id", 1),
+			target: notFound,
+			want:   true,
+		},
+		{
+			name:   "Same code through fmt.Errorf",
+			err:    fmt.Errorf("handler: %w", serr.New("user missing").WithCode("NOT_FOUND")),
+			target: notFound,
+			want:   true,
+		},
+		{
+			name:   "Different code",
+			err:    serr.Wrap(serr.New("denied").WithCode("FORBIDDEN"), "loading user"),
+			target: notFound,
+			want:   false,
+		},
+		{
+			name:   "No code on either",
+			err:    serr.Wrap(serr.New("user missing"), "loading user"),
+			target: serr.New("user missing"),
+			want:   false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := errors.Is(test.err, test.target); got != test.want {
+				t.Errorf("errors.Is() mismatch\n\t\twant=%t\n\t\t got=%t", test.want, got)
+			}
+		})
+	}
+	t.Run("IsCode", func(t *testing.T) {
+		if !notFound.IsCode("NOT_FOUND") {
+			t.Errorf("IsCode(\"NOT_FOUND\") should be true")
+		}
+		if notFound.IsCode("") || serr.New("bare").IsCode("") {
+			t.Errorf("IsCode(\"\") should be false")
+		}
+	})
+}
+
+func TestLazyArg(t *testing.T) {
+	var calls int
+	dump := func() any {
+		calls++
+		return "expensive"
+	}
+	err := serr.New("failed").Args("id", 1).LazyArg("dump", dump)
+	if calls != 0 {
+		t.Fatalf("Lazy arg evaluated before rendering; calls=%d", calls)
+	}
+	if want, got := "failed [id=1] [dump='expensive']", err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if calls != 1 {
+		t.Errorf("Lazy arg not evaluated once by Error(); calls=%d", calls)
+	}
+	attr, found := err.Attr("dump")
+	if !found || attr.Value.String() != "expensive" {
+		t.Errorf("Attr(\"dump\") not resolved; got %v", attr)
+	}
+	if calls != 1 {
+		t.Errorf("Lazy arg evaluated more than once; calls=%d", calls)
+	}
+}
+
+func TestLazyArgSharedSentinel(t *testing.T) {
+	errSentinel := serr.New("failed")
+	errSentinel.LazyArg("x", func() any { return 1 })
+	err := errSentinel.LazyArg("x", func() any { return 2 })
+	if want, got := "failed [x=2]", err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if want, got := "failed", errSentinel.Error(); want != got {
+		t.Errorf("Sentinel changed by LazyArg()\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var tests = []struct {
+		name      string
+		err       serr.SError
+		wantID    string
+		wantFound bool
+	}{
+		{
+			name: "Not set",
+			err:  serr.New("failed").Args("id", 1),
+		},
+		{
+			name:      "Set directly",
+			err:       serr.New("failed").Args("id", 1).WithRequestID("req-1"),
+			wantID:    "req-1",
+			wantFound: true,
+		},
+		{
+			name:      "Propagated through wraps",
+			err:       serr.Wrap(serr.Wrap(serr.New("failed").WithRequestID("req-2"), "middle"), "outer", "id", 1),
+			wantID:    "req-2",
+			wantFound: true,
+		},
+		{
+			name:      "Nearest wins",
+			err:       serr.Wrap(serr.New("failed").WithRequestID("req-inner"), "outer").WithRequestID("req-outer"),
+			wantID:    "req-outer",
+			wantFound: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, found := test.err.RequestID()
+			if id != test.wantID || found != test.wantFound {
+				t.Errorf("RequestID() mismatch\n\t\twant=%q, %t\n\t\t got=%q, %t",
+					test.wantID, test.wantFound, id, found)
+			}
+		})
+	}
+	t.Run("Rendered", func(t *testing.T) {
+		got := serr.New("failed").Args("id", 1).WithRequestID("req-1").Error()
+		if want := "failed [id=1] [request_id='req-1']"; want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+	t.Run("Strict sentinel", func(t *testing.T) {
+		err := serr.NewSentinel("not found", "id").Args("id", 1).WithRequestID("req-1")
+		if want, got := "not found [id=1] [request_id='req-1']", err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+	t.Run("Shared sentinel", func(t *testing.T) {
+		errNotFound := serr.New("not found")
+		errNotFound.WithRequestID("req-a")
+		err := errNotFound.WithRequestID("req-b")
+		if id, _ := err.RequestID(); id != "req-b" {
+			t.Errorf("RequestID() mismatch\n\t\twant=%q\n\t\t got=%q", "req-b", id)
+		}
+		if want, got := "not found [request_id='req-b']", err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		if _, found := errNotFound.RequestID(); found {
+			t.Errorf("WithRequestID() changed the sentinel: %s", errNotFound)
+		}
+	})
+}
+
+func TestRenderJSONArgs(t *testing.T) {
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want string
+	}{
+		{
+			name: "No args",
+			err:  serr.New("failed"),
+			want: "failed",
+		},
+		{
+			name: "Sorted keys with mixed values",
+			err: serr.New("failed").Args(
+				"path", "/tmp",
+				"count", 3,
+				"ok", false,
+				"ratio", 0.5,
+				"tags", []string{"a", "b"},
+			),
+			want: `failed {"count":3,"ok":false,"path":"/tmp","ratio":0.5,"tags":["a","b"]}`,
+		},
+		{
+			name: "Unencodable value",
+			err:  serr.New("failed").Args("fn", func() {}, "id", 1),
+			want: `failed {"fn":"%v","id":1}`,
+		},
+	}
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	serr.Render.ArgsFormat = serr.JSONArgs
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.err.Error()
+			want := test.want
+			if strings.Contains(want, "%v") {
+				want = fmt.Sprintf(want, test.err.GetArgs()[1])
+			}
+			if want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+			}
+			msg := test.err.String()
+			if rest := strings.TrimPrefix(got, msg); rest != "" && !json.Valid([]byte(rest)) {
+				t.Errorf("Rendered args are not valid JSON: %s", rest)
+			}
+		})
+	}
+}
+
+func TestEmptyMessage(t *testing.T) {
+	t.Run("Renders args without leading space", func(t *testing.T) {
+		var tests = []struct {
+			name string
+			err  serr.SError
+			want string
+		}{
+			{
+				name: "No args",
+				err:  serr.New(""),
+				want: "",
+			},
+			{
+				name: "With args",
+				err:  serr.New("").Args("id", 1, "name", "x"),
+				want: "[id=1] [name='x']",
+			},
+			{
+				name: "With op",
+				err:  serr.New("").Op("Load"),
+				want: "Load",
+			},
+			{
+				name: "With op and args",
+				err:  serr.New("").Args("id", 1).Op("Load"),
+				want: "Load: [id=1]",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if got := test.err.Error(); test.want != got {
+					t.Errorf("Result not equal\n\t\twant=%q\n\t\t got=%q", test.want, got)
+				}
+			})
+		}
+	})
+	t.Run("Strict", func(t *testing.T) {
+		defer func() { serr.StrictMessages = false }()
+		serr.StrictMessages = true
+		if !didPanic(func() { serr.New("") }) {
+			t.Errorf("New(\"\") should panic when StrictMessages is true")
+		}
+		if didPanic(func() { serr.New("ok") }) {
+			t.Errorf("New(\"ok\") should not panic when StrictMessages is true")
+		}
+	})
+}
+
+func TestWithPrefix(t *testing.T) {
+	inner := serr.New("query failed").WithPrefix("db").Args("id", 1, "table", "users")
+	outer := serr.Wrap(inner, "request failed").WithPrefix("http").Args("id", 2)
+	var tests = []struct {
+		name     string
+		err      serr.SError
+		want     string
+		wantKeys []string
+	}{
+		{
+			name:     "Inner",
+			err:      inner,
+			want:     "query failed [db.id=1] [db.table='users']",
+			wantKeys: []string{"db.id", "db.table"},
+		},
+		{
+			name:     "Outer",
+			err:      outer,
+			want:     "request failed [http.id=2]",
+			wantKeys: []string{"http.id"},
+		},
+		{
+			name:     "Lazy arg",
+			err:      serr.New("failed").WithPrefix("cache").LazyArg("size", func() any { return 3 }),
+			want:     "failed [cache.size=3]",
+			wantKeys: []string{"cache.size"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+			for _, key := range test.wantKeys {
+				if _, found := test.err.Attr(key); !found {
+					t.Errorf("Attr(%q) not found", key)
+				}
+			}
+		})
+	}
+	t.Run("Valid args are unprefixed", func(t *testing.T) {
+		defer func() { serr.RequireValidArgs = false }()
+		serr.RequireValidArgs = true
+		var tests = []struct {
+			name string
+			set  func(serr.SError) serr.SError
+			want string
+		}{
+			{
+				name: "Args",
+				set:  func(e serr.SError) serr.SError { return e.Args("id", 1) },
+				want: "lookup failed [db.id=1]",
+			},
+			{
+				name: "LazyArg",
+				set:  func(e serr.SError) serr.SError { return e.LazyArg("id", func() any { return 1 }) },
+				want: "lookup failed [db.id=1]",
+			},
+			{
+				name: "SetArg",
+				set:  func(e serr.SError) serr.SError { return e.SetArg("id", 1) },
+				want: "lookup failed [db.id=1]",
+			},
+			{
+				name: "Missing",
+				set:  func(e serr.SError) serr.SError { return e },
+				want: "lookup failed (missing required field: id)",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var err serr.SError
+				sentinel := serr.NewSentinel("lookup failed", "id").WithPrefix("db")
+				if didPanic(func() { err = test.set(sentinel) }) {
+					t.Fatalf("%s panicked on a declared key", test.name)
+				}
+				if got := err.Error(); test.want != got {
+					t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+				}
+				if invalid := serr.ValidateArgs(err); invalid != nil {
+					t.Errorf("ValidateArgs() should accept the declared key; got %v", invalid)
+				}
+			})
+		}
+		sentinel := serr.NewSentinel("lookup failed", "id").WithPrefix("db")
+		if !didPanic(func() { sentinel.Args("name", "x") }) {
+			t.Errorf("Args() should still reject an undeclared key")
+		}
+		if !didPanic(func() { sentinel.Args("db.id", 1) }) {
+			t.Errorf("Args() should reject a key given with the prefix")
+		}
+	})
+	t.Run("Flattened keys do not collide", func(t *testing.T) {
+		keys := make(map[any]int)
+		for _, err := range []serr.SError{inner, outer} {
+			args := err.GetArgs()
+			for i := 0; i < len(args); i += 2 {
+				keys[args[i]]++
+			}
+		}
+		for key, count := range keys {
+			if count > 1 {
+				t.Errorf("Key %v appears %d times", key, count)
+			}
+		}
+		if outer.TotalArgCount() != 3 {
+			t.Errorf("TotalArgCount() mismatch\n\t\twant=%d\n\t\t got=%d", 3, outer.TotalArgCount())
+		}
+	})
+}
+
+func TestPlain(t *testing.T) {
+	cause := errors.New("disk full")
+	sErr := serr.Wrap(cause, "write failed", "path", "/tmp/x")
+	plain := sErr.Plain()
+	if want, got := "write failed [path='/tmp/x']", plain.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if _, ok := plain.(serr.SError); ok {
+		t.Errorf("Plain() result should not be an SError")
+	}
+	if errors.Unwrap(plain) != nil || errors.Is(plain, cause) {
+		t.Errorf("Plain() result should not wrap any error")
+	}
+	sErr.Args("path", "/tmp/y")
+	if want, got := "write failed [path='/tmp/x']", plain.Error(); want != got {
+		t.Errorf("Plain() message changed\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+}
+
+func TestTypedError(t *testing.T) {
+	_, err := os.Open("/no/such/file")
+	sErr := serr.Wrap(err, "loading config", "name", "app")
+	want := "loading config [name='app'] (*serr.sError): " +
+		"open /no/such/file: no such file or directory (*fs.PathError): " +
+		"no such file or directory (syscall.Errno)"
+	if got := sErr.TypedError(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := sErr.Error(); strings.Contains(got, "(*fs.PathError)") {
+		t.Errorf("Error() should not include type annotations; got %s", got)
+	}
+}
+
+func TestAttrs(t *testing.T) {
+	t.Run("No args", func(t *testing.T) {
+		if attrs := serr.New("failed").Attrs(); attrs != nil {
+			t.Errorf("Attrs() should be nil; got %v", attrs)
+		}
+		if attrs := serr.New("failed").Args().Attrs(); attrs != nil {
+			t.Errorf("Attrs() after empty Args() should be nil; got %v", attrs)
+		}
+	})
+	t.Run("Single pair", func(t *testing.T) {
+		attrs := serr.New("failed").Args("id", 1).Attrs()
+		if len(attrs) != 1 {
+			t.Fatalf("Attrs() length mismatch\n\t\twant=%d\n\t\t got=%d", 1, len(attrs))
+		}
+		if attrs[0].Key != "id" || attrs[0].Value.Int64() != 1 {
+			t.Errorf("Attrs() mismatch\n\t\twant=id=1\n\t\t got=%v", attrs[0])
+		}
+	})
+}
+
+func TestOp(t *testing.T) {
+	var tests = []struct {
+		name    string
+		err     serr.SError
+		wantOps []string
+		want    string
+	}{
+		{
+			name: "No ops",
+			err:  serr.New("file not found"),
+			want: "file not found",
+		},
+		{
+			name:    "One op",
+			err:     serr.New("file not found").Op("ReadFile"),
+			wantOps: []string{"ReadFile"},
+			want:    "ReadFile: file not found",
+		},
+		{
+			name:    "Ordering",
+			err:     serr.New("file not found").Args("path", "app.yaml").Op("LoadConfig").Op("ReadFile"),
+			wantOps: []string{"LoadConfig", "ReadFile"},
+			want:    "ReadFile: LoadConfig: file not found [path='app.yaml']",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Ops(); !slices.Equal(test.wantOps, got) {
+				t.Errorf("Ops() mismatch\n\t\twant=%q\n\t\t got=%q", test.wantOps, got)
+			}
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestIsTemplate(t *testing.T) {
+	newTemplate := func() serr.SError {
+		return serr.New("http error").ValidArgs("status", "method")
+	}
+	newErr := func(args ...any) serr.SError {
+		return serr.Wrap(serr.New("http error").Args(args...), "calling api")
+	}
+	var tests = []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{
+			name:   "All declared args present with matching values",
+			err:    newErr("status", 404, "method", "GET", "url", "/x"),
+			target: newTemplate().Args("status", 404, "method", "GET"),
+			want:   true,
+		},
+		{
+			name:   "Declared args present, template has no values",
+			err:    newErr("status", 500, "method", "POST"),
+			target: newTemplate(),
+			want:   true,
+		},
+		{
+			name:   "Declared args present, template has some values",
+			err:    newErr("status", 404, "method", "PUT"),
+			target: newTemplate().Args("status", 404),
+			want:   true,
+		},
+		{
+			name:   "Value mismatch",
+			err:    newErr("status", 500, "method", "GET"),
+			target: newTemplate().Args("status", 404, "method", "GET"),
+			want:   false,
+		},
+		{
+			name:   "Missing declared arg",
+			err:    newErr("status", 404),
+			target: newTemplate().Args("status", 404),
+			want:   false,
+		},
+		{
+			name:   "Different message",
+			err:    serr.New("db error").Args("status", 404, "method", "GET"),
+			target: newTemplate().Args("status", 404, "method", "GET"),
+			want:   false,
+		},
+		{
+			name: "Declared template ignores values left by its Args()",
+			err:  newErr("status", 500, "method", "GET"),
+			target: func() error {
+				tmpl := newTemplate()
+				tmpl.Args("status", 404, "method", "GET")
+				return tmpl
+			}(),
+			want: true,
+		},
+		{
+			name:   "No valid args means no structural match",
+			err:    newErr("status", 404, "method", "GET"),
+			target: serr.New("http error").Args("status", 404, "method", "GET"),
+			want:   false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := errors.Is(test.err, test.target); got != test.want {
+				t.Errorf("errors.Is() mismatch\n\t\twant=%t\n\t\t got=%t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want serr.Classification
+	}{
+		{
+			name: "Nothing set",
+			err:  serr.Wrap(serr.New("inner"), "outer"),
+			want: serr.Classification{Level: slog.LevelError},
+		},
+		{
+			name: "Set at different depths",
+			err: serr.Wrap(
+				serr.Wrap(
+					serr.New("timeout").WithCode("TIMEOUT").WithRetryable(true),
+					"calling api",
+				).WithLevel(slog.LevelWarn),
+				"handling request",
+			),
+			want: serr.Classification{Code: "TIMEOUT", Retryable: true, Level: slog.LevelWarn},
+		},
+		{
+			name: "Nearest wins",
+			err: serr.Wrap(
+				serr.New("timeout").WithCode("TIMEOUT").WithRetryable(true).WithLevel(slog.LevelWarn),
+				"handling request",
+			).WithCode("UNAVAILABLE").WithRetryable(false),
+			want: serr.Classification{Code: "UNAVAILABLE", Retryable: false, Level: slog.LevelWarn},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Classify(); got != test.want {
+				t.Errorf("Classify() mismatch\n\t\twant=%+v\n\t\t got=%+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	stdErr := errors.New("disk full")
+	sentinel := serr.New("write failed").Args("path", "/tmp/x")
+	var tests = []struct {
+		name     string
+		err      error
+		args     []any
+		want     string
+		wantArgs int
+	}{
+		{
+			name:     "Standard error",
+			err:      stdErr,
+			args:     []any{"device", "sda"},
+			want:     "disk full [device='sda']",
+			wantArgs: 1,
+		},
+		{
+			name:     "SError keeps existing args",
+			err:      sentinel,
+			args:     []any{"size", 10},
+			want:     "write failed [path='/tmp/x'] [size=10]",
+			wantArgs: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := test.err.Error()
+			got := serr.Enrich(test.err, test.args...)
+			if got.Error() != test.want {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got.Error())
+			}
+			if !errors.Is(got, test.err) {
+				t.Errorf("errors.Is() should match the original error")
+			}
+			if got.TotalArgCount() != test.wantArgs {
+				t.Errorf("TotalArgCount() mismatch\n\t\twant=%d\n\t\t got=%d", test.wantArgs, got.TotalArgCount())
+			}
+			if after := test.err.Error(); before != after {
+				t.Errorf("Original error changed\n\t\twant=%s\n\t\t got=%s", before, after)
+			}
+		})
+	}
+	t.Run("Wrapped chain", func(t *testing.T) {
+		_, pathErr := os.Open("/no/such/file")
+		err := serr.Enrich(fmt.Errorf("loading config: %w", pathErr), "k", "v")
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("errors.Is() should reach fs.ErrNotExist through the enriched chain")
+		}
+		var target *fs.PathError
+		if !errors.As(err, &target) {
+			t.Errorf("errors.As() should find the *fs.PathError in the enriched chain")
+		}
+	})
+	t.Run("Nil", func(t *testing.T) {
+		if err := serr.Enrich(nil, "k", "v"); err != nil {
+			t.Errorf("Enrich(nil) should be nil; got %v", err)
+		}
+	})
+}
+
+func TestAnnotate(t *testing.T) {
+	errRead := errors.New("read failed")
+	load := func(fail bool) (err error) {
+		defer serr.Annotate(&err, "loading config", "path", "app.yaml")
+		if fail {
+			err = errRead
+		}
+		return err
+	}
+	t.Run("Nil error", func(t *testing.T) {
+		if err := load(false); err != nil {
+			t.Errorf("Annotate() should leave a nil error nil; got %v", err)
+		}
+		serr.Annotate(nil, "ignored")
+	})
+	t.Run("Non-nil error", func(t *testing.T) {
+		err := load(true)
+		if want, got := "loading config [path='app.yaml']", err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		if !errors.Is(err, errRead) {
+			t.Errorf("errors.Is() should find the annotated error")
+		}
+	})
+}
+
+func TestNilError(t *testing.T) {
+	doThing := func() error { return nil }
+	t.Run("Wrap", func(t *testing.T) {
+		if err := serr.Wrap(doThing(), "doing thing", "id", 1); err != nil {
+			t.Errorf("Wrap(nil) should be nil; got %v", err)
+		}
+	})
+	t.Run("Cast", func(t *testing.T) {
+		if err := serr.Cast(doThing(), "id", 1); err != nil {
+			t.Errorf("Cast(nil) should be nil; got %v", err)
+		}
+	})
+	t.Run("Returned as error", func(t *testing.T) {
+		wrapped := func() error {
+			return serr.Wrap(doThing(), "doing thing")
+		}
+		// A nil SError converts to a nil error interface, not a typed nil.
+		if err := wrapped(); err != nil {
+			t.Errorf("Wrap(nil) returned as error should be nil; got %#v", err)
+		}
+	})
+}
+
+func TestAsRecords(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := serr.Wrap(
+		serr.Wrap(cause, "query failed", "table", "users", "attempt", 2),
+		"loading user",
+		"id", 42,
+	)
+	want := []map[string]any{
+		{"message": "loading user", "args": map[string]any{"id": 42}},
+		{"message": "query failed", "args": map[string]any{"table": "users", "attempt": 2}},
+		{"message": "connection refused"},
+	}
+	got := err.AsRecords()
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("AsRecords() mismatch\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+}
+
+func TestRenderMaxWrappedLen(t *testing.T) {
+	huge := errors.New("validation failed: " + strings.Repeat("x", 500))
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	serr.Render.MaxWrappedLen = 21
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want string
+	}{
+		{
+			name: "Cast",
+			err:  serr.Cast(huge, "form", "signup"),
+			want: fmt.Sprintf("validation%sxxxxxxxxxx [form='signup']", serr.EllipsisRune),
+		},
+		{
+			name: "Enrich",
+			err:  serr.Enrich(huge, "form", "signup"),
+			want: fmt.Sprintf("validation%sxxxxxxxxxx [form='signup']", serr.EllipsisRune),
+		},
+		{
+			name: "Short message untouched",
+			err:  serr.Cast(errors.New("bad input")),
+			want: "bad input",
+		},
+		{
+			name: "SError message untouched",
+			err:  serr.New(strings.Repeat("y", 30)),
+			want: strings.Repeat("y", 30),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+
+	wrapped := serr.Wrap(huge, "saving", "form", "signup")
+	excerpt := fmt.Sprintf("validation%sxxxxxxxxxx", serr.EllipsisRune)
+	want := "saving [form='signup']: " + excerpt
+	if got := wrapped.ChainError(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := wrapped.BaseMessages()[1]; excerpt != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", excerpt, got)
+	}
+	if got := wrapped.AsRecords()[1]["message"]; excerpt != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", excerpt, got)
+	}
+	b, err := json.Marshal(wrapped)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	wantJSON := `{"message":"saving","args":{"form":"signup"},"wrapped":{"message":"` + excerpt + `"}}`
+	if got := string(b); wantJSON != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", wantJSON, got)
+	}
+}
+
+type queryError struct {
+	query string
+	rows  int
+}
+
+func (e *queryError) Error() string {
+	return "query failed"
+}
+
+func (e *queryError) ErrorArgs() []any {
+	return []any{"query", e.query, "rows", e.rows}
+}
+
+func TestArgsProvider(t *testing.T) {
+	err := serr.Wrap(&queryError{query: "SELECT 1", rows: 0}, "loading user", "id", 42)
+	if got := err.TotalArgCount(); got != 3 {
+		t.Errorf("TotalArgCount() mismatch\n\t\twant=%d\n\t\t got=%d", 3, got)
+	}
+	want := []map[string]any{
+		{"message": "loading user", "args": map[string]any{"id": 42}},
+		{"message": "query failed", "args": map[string]any{"query": "SELECT 1", "rows": 0}},
+	}
+	if got := err.AsRecords(); !reflect.DeepEqual(want, got) {
+		t.Errorf("AsRecords() mismatch\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+}
+
+func TestValidArgsTyped(t *testing.T) {
+	spec := map[string]reflect.Kind{
+		"path":  reflect.String,
+		"size":  reflect.Int,
+		"flags": reflect.Slice,
+	}
+	var tests = []struct {
+		name      string
+		args      []any
+		wantPanic bool
+	}{
+		{
+			name: "Matching kinds",
+			args: []any{"path", "/tmp", "size", 10, "flags", []string{"r"}},
+		},
+		{
+			name: "Nil for nillable kind",
+			args: []any{"flags", nil},
+		},
+		{
+			name: "Lazy value not checked",
+			args: nil,
+		},
+		{
+			name:      "Wrong kind",
+			args:      []any{"path", "/tmp", "size", "10"},
+			wantPanic: true,
+		},
+		{
+			name:      "Nil for non-nillable kind",
+			args:      []any{"size", nil},
+			wantPanic: true,
+		},
+		{
+			name:      "Undeclared key",
+			args:      []any{"mode", 0644},
+			wantPanic: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sErr := serr.New("write failed").ValidArgsTyped(spec)
+			panicked := didPanic(func() {
+				sErr.Args(test.args...).LazyArg("size", func() any { return "lazy" })
+			})
+			if panicked != test.wantPanic {
+				t.Errorf("Panic mismatch\n\t\twant=%t\n\t\t got=%t", test.wantPanic, panicked)
+			}
+		})
+	}
+}
+
+func TestFreezeMessage(t *testing.T) {
+	cause := errors.New("disk full")
+	sErr := serr.Wrap(cause, "write failed", "path", "/tmp/x", "size", 10).Op("Save")
+	want := sErr.Error()
+	frozen := sErr.FreezeMessage()
+	if got := frozen.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := frozen.String(); want != got {
+		t.Errorf("Base message not frozen\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if frozen.GetArgs() != nil || frozen.ArgCount() != 0 || frozen.Ops() != nil {
+		t.Errorf("Args and ops should be empty; got args=%v ops=%v", frozen.GetArgs(), frozen.Ops())
+	}
+	if !errors.Is(frozen, cause) {
+		t.Errorf("errors.Is() should still find the wrapped error")
+	}
+	if got := len(frozen.AsRecords()); got != 2 {
+		t.Errorf("AsRecords() length mismatch\n\t\twant=%d\n\t\t got=%d", 2, got)
+	}
+	t.Run("Missing valid args", func(t *testing.T) {
+		defer func() { serr.RequireValidArgs = false }()
+		serr.RequireValidArgs = true
+		sentinel := serr.NewSentinel("not found", "id")
+		want := sentinel.Error()
+		if got := sentinel.FreezeMessage().Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+}
+
+func TestHasCycle(t *testing.T) {
+	t.Run("Normal chain", func(t *testing.T) {
+		err := serr.Wrap(serr.Wrap(errors.New("root"), "middle", "k", 1), "outer")
+		if err.HasCycle() {
+			t.Errorf("HasCycle() should be false for a normal chain")
+		}
+	})
+	t.Run("Deliberate cycle", func(t *testing.T) {
+		inner := serr.New("inner")
+		outer := serr.Wrap(inner, "outer")
+		inner.Err(outer)
+		if !outer.HasCycle() {
+			t.Errorf("HasCycle() should be true for a cyclic chain")
+		}
+		if got := outer.TotalArgCount(); got != 0 {
+			t.Errorf("TotalArgCount() should terminate on a cyclic chain; got %d", got)
+		}
+	})
+	t.Run("Err on a clone-wrapped error", func(t *testing.T) {
+		inner := serr.New("inner").Args("a", 1)
+		err := serr.New("outer").Args("b", 2).Err(inner)
+		if err.HasCycle() {
+			t.Errorf("HasCycle() should be false")
+		}
+		if got := len(err.AsRecords()); got != 2 {
+			t.Errorf("AsRecords() length mismatch\n\t\twant=%d\n\t\t got=%d", 2, got)
+		}
+	})
+}
+
+func TestWrapfArgs(t *testing.T) {
+	cause := errors.New("permission denied")
+	err := serr.WrapfArgs(cause, "opening %s for %s", []any{"/etc/app.yaml", "reading"}, "attempt", 3)
+	if want, got := "opening /etc/app.yaml for reading", err.String(); want != got {
+		t.Errorf("Message mismatch\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	attrs := err.Attrs()
+	if len(attrs) != 1 || attrs[0].Key != "attempt" || attrs[0].Value.Int64() != 3 {
+		t.Errorf("Attrs() mismatch\n\t\twant=[attempt=3]\n\t\t got=%v", attrs)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is() should find the wrapped error")
+	}
+	if err := serr.WrapfArgs(nil, "opening %s", []any{"x"}); err != nil {
+		t.Errorf("WrapfArgs(nil) should be nil; got %v", err)
+	}
+}
+
+func TestExcerptWithLenMax(t *testing.T) {
+	var tests = []struct {
+		name     string
+		source   string
+		maxWidth int
+		want     string
+	}{
+		{
+			name:     "Fits without excerpting",
+			source:   "ABCDEFGHIJ",
+			maxWidth: 20,
+			want:     "[len=10] ABCDEFGHIJ",
+		},
+		{
+			name:     "Exactly fits",
+			source:   "ABCDEFGHIJ",
+			maxWidth: 19,
+			want:     "[len=10] ABCDEFGHIJ",
+		},
+		{
+			name:     "Excerpted to fit",
+			source:   "ABCDEFGHIJ",
+			maxWidth: 16,
+			want:     fmt.Sprintf("[len=10] ABC%sHIJ", serr.EllipsisRune),
+		},
+		{
+			name:     "Only room for prefix",
+			source:   Xs,
+			maxWidth: 5,
+			want:     "[len=",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := serr.ExcerptWithLenMax(test.source, test.maxWidth)
+			if gotLen := utf8.RuneCountInString(got); gotLen > test.maxWidth {
+				t.Errorf("Result too long\n\t\twant<=%d\n\t\t  got=%d", test.maxWidth, gotLen)
+			}
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestBaseMessage(t *testing.T) {
+	err := serr.Wrap(
+		serr.New("query failed").Args("table", "users").Op("Load"),
+		"loading user",
+		"id", 42,
+	)
+	if want, got := "loading user", err.BaseMessage(); want != got {
+		t.Errorf("BaseMessage() mismatch\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	want := []string{"loading user", "query failed"}
+	if got := err.BaseMessages(); !slices.Equal(want, got) {
+		t.Errorf("BaseMessages() mismatch\n\t\twant=%q\n\t\t got=%q", want, got)
+	}
+	if err.BaseMessage() == err.Error() {
+		t.Errorf("BaseMessage() should exclude args; got %s", err.BaseMessage())
+	}
+}
+
+func TestRenderSpacing(t *testing.T) {
+	var tests = []struct {
+		name         string
+		msg          string
+		leadingSpace string
+		argSpacing   string
+		want         string
+	}{
+		{
+			name:         "Default",
+			msg:          "failed",
+			leadingSpace: " ",
+			argSpacing:   " ",
+			want:         "failed [id=1] [name='x']",
+		},
+		{
+			name:         "No leading space",
+			msg:          "failed",
+			leadingSpace: "",
+			argSpacing:   " ",
+			want:         "failed[id=1] [name='x']",
+		},
+		{
+			name:         "No leading space, empty message",
+			msg:          "",
+			leadingSpace: "",
+			argSpacing:   " ",
+			want:         "[id=1] [name='x']",
+		},
+		{
+			name:         "Custom spacing",
+			msg:          "failed",
+			leadingSpace: ": ",
+			argSpacing:   ", ",
+			want:         "failed: [id=1], [name='x']",
+		},
+	}
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serr.Render.LeadingSpace = test.leadingSpace
+			serr.Render.ArgSpacing = test.argSpacing
+			got := serr.New(test.msg).Args("id", 1, "name", "x").Error()
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%q\n\t\t got=%q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestIsType(t *testing.T) {
+	_, pathErr := os.Open("/no/such/file")
+	var tests = []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Wrapped path error",
+			err:  serr.Wrap(serr.Wrap(pathErr, "reading"), "loading config"),
+			want: true,
+		},
+		{
+			name: "Wrapped via fmt.Errorf",
+			err:  fmt.Errorf("startup: %w", serr.Wrap(pathErr, "reading")),
+			want: true,
+		},
+		{
+			name: "No path error",
+			err:  serr.Wrap(errors.New("boom"), "loading config"),
+			want: false,
+		},
+		{
+			name: "Nil",
+			err:  nil,
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serr.IsType[*fs.PathError](test.err); got != test.want {
+				t.Errorf("IsType() mismatch\n\t\twant=%t\n\t\t got=%t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestHelpURL(t *testing.T) {
+	const url = "https://example.com/errors/quota"
+	inner := serr.New("quota exceeded").Args("limit", 10).WithHelpURL(url)
+	outer := serr.Wrap(inner, "uploading file")
+	t.Run("Get", func(t *testing.T) {
+		for _, err := range []serr.SError{inner, outer} {
+			got, found := err.HelpURL()
+			if !found || got != url {
+				t.Errorf("HelpURL() mismatch\n\t\twant=%s\n\t\t got=%s", url, got)
+			}
+		}
+		if _, found := serr.New("bare").HelpURL(); found {
+			t.Errorf("HelpURL() should not be found when not set")
+		}
+	})
+	t.Run("Rendered", func(t *testing.T) {
+		if got := inner.Error(); got != "quota exceeded [limit=10]" {
+			t.Errorf("HelpURL should not render by default; got %s", got)
+		}
+		defer func() { serr.Render = serr.DefaultRenderConfig }()
+		serr.Render.ShowHelpURL = true
+		want := "quota exceeded [limit=10] (see: " + url + ")"
+		if got := inner.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		want = "uploading file (see: " + url + ")"
+		if got := outer.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+}
+
+func TestArgKeys(t *testing.T) {
+	err := serr.Wrap(
+		serr.Wrap(
+			serr.New("query failed").Args("table", "users", "id", 7),
+			"loading user",
+			"id", 42, "attempt", 2,
+		),
+		"handling request",
+		"route", "/users", "attempt", 3,
+	)
+	var tests = []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{
+			name: "ArgKeys",
+			got:  err.ArgKeys(),
+			want: []string{"attempt", "route"},
+		},
+		{
+			name: "AllArgKeys",
+			got:  err.AllArgKeys(),
+			want: []string{"attempt", "id", "route", "table"},
+		},
+		{
+			name: "No args",
+			got:  serr.New("bare").ArgKeys(),
+			want: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !slices.Equal(test.want, test.got) {
+				t.Errorf("Keys mismatch\n\t\twant=%q\n\t\t got=%q", test.want, test.got)
+			}
+		})
+	}
+}
+
+func TestHideArgsInError(t *testing.T) {
+	err := serr.New("invalid password").Args("user_id", 42).HideArgsInError()
+	if want, got := "invalid password", err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	attr, found := err.Attr("user_id")
+	if !found || attr.Value.Int64() != 42 {
+		t.Errorf("Attr(\"user_id\") should still be available; got %v", attr)
+	}
+	err = err.Args("user_id", 43, "attempt", 2)
+	if want, got := "invalid password", err.Error(); want != got {
+		t.Errorf("Args added later should be hidden too\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := len(err.Attrs()); got != 2 {
+		t.Errorf("Attrs() length mismatch\n\t\twant=%d\n\t\t got=%d", 2, got)
+	}
+}
+
+func TestStrictArgs(t *testing.T) {
+	t.Run("Strict", func(t *testing.T) {
+		if !didPanic(func() { serr.New("failed").Args("id", 1, "name") }) {
+			t.Errorf("Args() with odd args should panic when StrictArgs is true")
+		}
+	})
+	t.Run("Lenient", func(t *testing.T) {
+		defer func() { serr.StrictArgs = true }()
+		serr.StrictArgs = false
+		var err serr.SError
+		if didPanic(func() { err = serr.New("failed").Args("id", 1, "name") }) {
+			t.Fatalf("Args() with odd args should not panic when StrictArgs is false")
+		}
+		if want, got := "failed [id=1]", err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+		if got := err.ArgCount(); got != 1 {
+			t.Errorf("ArgCount() mismatch\n\t\twant=%d\n\t\t got=%d", 1, got)
+		}
+	})
+}
+
+func TestAllAttrs(t *testing.T) {
+	err := serr.Wrap(
+		serr.Wrap(
+			serr.New("query failed").Args("table", "users", "id", 7),
+			"loading user",
+			"id", 42, "attempt", 2,
+		),
+		"handling request",
+		"route", "/users",
+	)
+	render := func(attrs []slog.Attr) string {
+		parts := make([]string, len(attrs))
+		for i, attr := range attrs {
+			parts[i] = attr.String()
+		}
+		return strings.Join(parts, " ")
+	}
+	t.Run("Outer wins", func(t *testing.T) {
+		want := "route=/users id=42 attempt=2 table=users"
+		if got := render(err.AllAttrs()); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+	t.Run("Inner overrides", func(t *testing.T) {
+		defer func() { serr.InnerAttrsOverride = false }()
+		serr.InnerAttrsOverride = true
+		want := "route=/users id=7 attempt=2 table=users"
+		if got := render(err.AllAttrs()); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+}
+
+func TestNewHere(t *testing.T) {
+	t.Run("Captured", func(t *testing.T) {
+		err := serr.NewHere("failed")
+		attr, found := err.Attr(serr.FuncKey)
+		want := "github.com/mikeschinkel/go-serr_test.TestNewHere.func1"
+		if !found || attr.Value.String() != want {
+			t.Errorf("Func mismatch\n\t\twant=%s\n\t\t got=%s", want, attr.Value.String())
+		}
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		defer func() { serr.CaptureCaller = true }()
+		serr.CaptureCaller = false
+		if _, found := serr.NewHere("failed").Attr(serr.FuncKey); found {
+			t.Errorf("Func should not be attached when CaptureCaller is false")
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	limited := serr.New("rate limited").Args("limit", 100).WithRetryAfter(30 * time.Second)
+	var tests = []struct {
+		name      string
+		err       serr.SError
+		want      time.Duration
+		wantFound bool
+	}{
+		{
+			name: "Not set",
+			err:  serr.New("failed"),
+		},
+		{
+			name:      "Set directly",
+			err:       limited,
+			want:      30 * time.Second,
+			wantFound: true,
+		},
+		{
+			name:      "Propagated through a wrap",
+			err:       serr.Wrap(limited, "calling api", "url", "/x"),
+			want:      30 * time.Second,
+			wantFound: true,
+		},
+		{
+			name:      "Nearest wins",
+			err:       serr.Wrap(limited, "calling api").WithRetryAfter(time.Minute),
+			want:      time.Minute,
+			wantFound: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := test.err.RetryAfter()
+			if got != test.want || found != test.wantFound {
+				t.Errorf("RetryAfter() mismatch\n\t\twant=%v, %t\n\t\t got=%v, %t", test.want, test.wantFound, got, found)
+			}
+		})
+	}
+}
+
+func TestChainError(t *testing.T) {
+	err := serr.Wrap(
+		serr.Wrap(errors.New("connection refused"), "query failed", "table", "users"),
+		"loading user",
+		"id", 42,
+	)
+	var tests = []struct {
+		name           string
+		innermostFirst bool
+		maxWrappedLen  int
+		want           string
+	}{
+		{
+			name: "Outermost first",
+			want: "loading user [id=42]: query failed [table='users']: connection refused",
+		},
+		{
+			name:           "Innermost first",
+			innermostFirst: true,
+			want:           "connection refused: query failed [table='users']: loading user [id=42]",
+		},
+		{
+			name:           "Innermost first with wrapped message excerpted",
+			innermostFirst: true,
+			maxWrappedLen:  10,
+			want:           serr.Excerpt("connection refused", 10) + ": query failed [table='users']: loading user [id=42]",
+		},
+	}
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serr.Render.InnermostFirst = test.innermostFirst
+			serr.Render.MaxWrappedLen = test.maxWrappedLen
+			if got := err.ChainError(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+			if want, got := "loading user [id=42]", err.Error(); want != got {
+				t.Errorf("Error() should be unaffected\n\t\twant=%s\n\t\t got=%s", want, got)
+			}
+		})
+	}
+}
+
+func TestDepth(t *testing.T) {
+	root := errors.New("root")
+	var tests = []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "Nil", err: nil, want: 0},
+		{name: "Standard error", err: root, want: 1},
+		{name: "SError with args", err: serr.New("a").Args("k", 1).WithCode("A"), want: 1},
+		{name: "Wrapped twice", err: serr.Wrap(serr.Wrap(root, "middle", "k", 1), "outer"), want: 3},
+		{name: "Mixed chain", err: fmt.Errorf("top: %w", serr.Wrap(root, "middle")), want: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serr.Depth(test.err); got != test.want {
+				t.Errorf("Depth() mismatch\n\t\twant=%d\n\t\t got=%d", test.want, got)
+			}
+			if sErr, ok := test.err.(serr.SError); ok && sErr.ChainDepth() != test.want {
+				t.Errorf("ChainDepth() mismatch\n\t\twant=%d\n\t\t got=%d", test.want, sErr.ChainDepth())
+			}
+		})
+	}
+}
+
+func TestLogged(t *testing.T) {
+	err := serr.New("failed").Args("id", 1)
+	if err.IsLogged() {
+		t.Errorf("IsLogged() should be false before Logged()")
+	}
+	logged := err.Logged()
+	if !logged.IsLogged() {
+		t.Errorf("IsLogged() should be true after Logged()")
+	}
+	if !logged.Clone().IsLogged() || !logged.CloneWrap().IsLogged() {
+		t.Errorf("IsLogged() should survive Clone() and CloneWrap()")
+	}
+	if !logged.Args("id", 2).IsLogged() {
+		t.Errorf("IsLogged() should survive Args()")
+	}
+	wrapped := serr.Wrap(serr.Wrap(logged, "middle"), "top", "k", "v")
+	if !wrapped.IsLogged() {
+		t.Errorf("IsLogged() should be true at the top of the chain")
+	}
+	errSentinel := serr.New("failed")
+	_ = errSentinel.Logged()
+	if errSentinel.IsLogged() || errSentinel.Args("id", 1).IsLogged() {
+		t.Errorf("Logged() should not mark the sentinel it was called on")
+	}
+}
+
+func TestSetArg(t *testing.T) {
+	var tests = []struct {
+		name string
+		err  serr.SError
+		key  string
+		want []any
+	}{
+		{
+			name: "Update existing",
+			err:  serr.New("failed").Args("id", 1, "name", "x"),
+			key:  "id",
+			want: []any{"id", 2, "name", "x"},
+		},
+		{
+			name: "Add new",
+			err:  serr.New("failed").Args("name", "x"),
+			key:  "id",
+			want: []any{"name", "x", "id", 2},
+		},
+		{
+			name: "Update first of repeated",
+			err:  serr.New("failed").Args("id", 1, "id", 1),
+			key:  "id",
+			want: []any{"id", 2, "id", 1},
+		},
+		{
+			name: "Update prefixed",
+			err:  serr.New("failed").WithPrefix("db").Args("id", 1),
+			key:  "id",
+			want: []any{"db.id", 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := slices.Clone(test.err.GetArgs())
+			got := test.err.SetArg(test.key, 2).GetArgs()
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", test.want, got)
+			}
+			if !reflect.DeepEqual(before, test.err.GetArgs()) {
+				t.Errorf("SetArg() changed args of the original error\n\t\twant=%v\n\t\t got=%v", before, test.err.GetArgs())
+			}
+		})
+	}
+}
+
+func TestWalkDepth(t *testing.T) {
+	inner := serr.New("inner").Args("a", 1)
+	middle := serr.Wrap(inner, "middle").Args("b", 2)
+	outer := serr.Wrap(middle, "outer", "c", 3)
+
+	var depths []int
+	var msgs []string
+	outer.WalkDepth(func(depth int, sErr serr.SError) bool {
+		depths = append(depths, depth)
+		msgs = append(msgs, sErr.BaseMessage())
+		return true
+	})
+	want := []int{0, 1, 2}
+	if !slices.Equal(want, depths) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, depths)
+	}
+	wantMsgs := []string{"outer", "middle", "inner"}
+	if !slices.Equal(wantMsgs, msgs) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", wantMsgs, msgs)
+	}
+
+	msgs = nil
+	outer.Walk(func(sErr serr.SError) bool {
+		msgs = append(msgs, sErr.BaseMessage())
+		return len(msgs) < 2
+	})
+	wantMsgs = []string{"outer", "middle"}
+	if !slices.Equal(wantMsgs, msgs) {
+		t.Errorf("Walk() did not stop when fn returned false\n\t\twant=%v\n\t\t got=%v", wantMsgs, msgs)
+	}
+}
+
+func TestIsEnrichedSentinel(t *testing.T) {
+	sentinel := serr.New("not found")
+	var tests = []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "Enriched error", err: sentinel.Args("id", 1), target: sentinel, want: true},
+		{name: "Enriched both", err: sentinel.Args("id", 1), target: sentinel.Args("id", 2), want: true},
+		{name: "Enriched and wrapped", err: serr.Wrap(sentinel.Args("id", 1), "lookup"), target: sentinel.Args("k", "v"), want: true},
+		{name: "Same message, different sentinel", err: serr.New("not found").Args("id", 1), target: sentinel.Args("id", 1), want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := errors.Is(test.err, test.target)
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%t\n\t\t got=%t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderMaxErrorLen(t *testing.T) {
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	serr.Render.MaxErrorLen = 21
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want string
+	}{
+		{
+			name: "Long args excerpted",
+			err:  serr.New("query failed").Args("sql", strings.Repeat("x", 500), "table", "users"),
+			want: fmt.Sprintf("query fail%se='users']", serr.EllipsisRune),
+		},
+		{
+			name: "Short error untouched",
+			err:  serr.New("failed").Args("id", 1),
+			want: "failed [id=1]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.err.Error()
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+			if n := utf8.RuneCountInString(got); n > serr.Render.MaxErrorLen {
+				t.Errorf("Error() exceeds MaxErrorLen: %d > %d", n, serr.Render.MaxErrorLen)
+			}
+		})
+	}
+}
+
+func TestRenderShowArgCount(t *testing.T) {
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	serr.Render.ShowArgCount = true
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want string
+	}{
+		{
+			name: "Three args",
+			err:  serr.New("failed").Args("a", 1, "b", 2, "c", 3),
+			want: "failed (3 fields) [a=1] [b=2] [c=3]",
+		},
+		{
+			name: "One arg",
+			err:  serr.New("failed").Args("a", 1),
+			want: "failed (1 field) [a=1]",
+		},
+		{
+			name: "No args",
+			err:  serr.New("failed"),
+			want: "failed",
+		},
+		{
+			name: "Empty message",
+			err:  serr.New("").Args("a", 1, "b", 2),
+			want: "(2 fields) [a=1] [b=2]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	var tests = []struct {
+		name     string
+		err      error
+		want     string
+		wantArgs []any
+	}{
+		{
+			name: "Nil",
+			err:  nil,
+		},
+		{
+			name: "Plain error",
+			err:  fs.ErrNotExist,
+			want: "file does not exist",
+		},
+		{
+			name: "Mixed chain",
+			err: fmt.Errorf("load config: %w",
+				serr.Wrap(
+					fmt.Errorf("open: %w", fs.ErrNotExist),
+					"read failed", "path", "/etc/app.json",
+				),
+			),
+			want:     "load config: read failed: open: file does not exist",
+			wantArgs: []any{"path", "/etc/app.json"},
+		},
+		{
+			name: "SError chain",
+			err: serr.Wrap(
+				serr.New("not found").Args("id", 1),
+				"lookup failed", "table", "users",
+			),
+			want:     "lookup failed: not found",
+			wantArgs: []any{"table", "users", "id", 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := serr.Flatten(test.err)
+			if test.err == nil {
+				if got != nil {
+					t.Errorf("Flatten(nil) should be nil; got %v", got)
+				}
+				return
+			}
+			if got.BaseMessage() != test.want {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got.BaseMessage())
+			}
+			if !reflect.DeepEqual(test.wantArgs, got.GetArgs()) {
+				t.Errorf("Args not equal\n\t\twant=%v\n\t\t got=%v", test.wantArgs, got.GetArgs())
+			}
+			if got.Unwrap() != nil {
+				t.Errorf("Flatten() should not wrap an error; got %v", got.Unwrap())
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	inner := serr.New("query failed").Tag("db", "postgres").Tag("kind", "timeout")
+	outer := serr.Wrap(inner, "load failed").Tag("kind", "load").Tag("service", "api")
+
+	want := map[string]string{"db": "postgres", "kind": "timeout"}
+	if got := inner.Tags(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+	want = map[string]string{"db": "postgres", "kind": "load", "service": "api"}
+	if got := outer.Tags(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+	if got := serr.New("failed").Tags(); got != nil {
+		t.Errorf("Tags() without tags should be nil; got %v", got)
+	}
+	wantMsg := "query failed"
+	if got := inner.Error(); wantMsg != got {
+		t.Errorf("Tags should not render in Error()\n\t\twant=%s\n\t\t got=%s", wantMsg, got)
+	}
+	if len(inner.GetArgs()) != 0 {
+		t.Errorf("Tags should not be args; got %v", inner.GetArgs())
+	}
+}
+
+func TestSnapshotArgs(t *testing.T) {
+	err := serr.New("failed").Args("id", 1)
+	want := []any{"id", 1}
+	restore := err.SnapshotArgs()
+
+	debugErr := err.Args("debug", true).WithRequestID("abc")
+	if got := debugErr.GetArgs(); reflect.DeepEqual(want, got) {
+		t.Fatalf("Args() should have changed the args; got %v", got)
+	}
+
+	restored := restore()
+	if got := restored.GetArgs(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+	wantMsg := "failed [id=1]"
+	if got := restored.Error(); wantMsg != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", wantMsg, got)
+	}
+	if got := debugErr.GetArgs(); reflect.DeepEqual(want, got) {
+		t.Errorf("Restoring should not change the error with debug args; got %v", got)
+	}
+
+	if got := restore().GetArgs(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Restoring twice failed\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want string
+	}{
+		{
+			name: "Compliant",
+			err:  serr.NewSentinel("not found", "id", "table").Args("id", 1),
+		},
+		{
+			name: "No valid args declared",
+			err:  serr.New("not found").Args("id", 1),
+		},
+		{
+			name: "Non-compliant",
+			err:  serr.New("not found").ValidArgs("id").Args("id", 1, "table", "users", "db", "main"),
+			want: "'not found' has invalid arg keys: db, table; valid keys are: id",
+		},
+		{
+			name: "Non-compliant in earlier call",
+			err:  serr.New("not found").ValidArgs("id").Args("table", "users").Args("id", 1),
+			want: "'not found' has invalid arg keys: table; valid keys are: id",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := serr.ValidateArgs(test.err)
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRelated(t *testing.T) {
+	cause := errors.New("connection refused")
+	context := serr.New("retrying request").Args("attempt", 3)
+	wrapped := errors.New("timeout")
+	err := serr.Wrap(wrapped, "fetch failed").
+		WithRelated("cause", cause).
+		WithRelated("context", context).
+		WithRelated("ignored", nil)
+
+	want := map[string]error{"cause": cause, "context": context}
+	if got := err.Related(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+	if !errors.Is(err, wrapped) {
+		t.Errorf("errors.Is() should find the wrapped error")
+	}
+	if errors.Is(err, cause) {
+		t.Errorf("errors.Is() should not find a related error")
+	}
+	if got := serr.New("failed").Related(); got != nil {
+		t.Errorf("Related() without related errors should be nil; got %v", got)
+	}
+}
+
+func TestFindArg(t *testing.T) {
+	inner := serr.New("query failed").Args("db.host", "localhost", "rows", -1)
+	err := serr.Wrap(inner, "load failed", "user", "alice", "count", 3)
+
+	var tests = []struct {
+		name      string
+		pred      func(key string, value any) bool
+		wantKey   string
+		wantValue any
+		wantFound bool
+	}{
+		{
+			name: "Negative int value",
+			pred: func(_ string, value any) bool {
+				n, ok := value.(int)
+				return ok && n < 0
+			},
+			wantKey:   "rows",
+			wantValue: -1,
+			wantFound: true,
+		},
+		{
+			name: "Key prefix",
+			pred: func(key string, _ any) bool {
+				return strings.HasPrefix(key, "db.")
+			},
+			wantKey:   "db.host",
+			wantValue: "localhost",
+			wantFound: true,
+		},
+		{
+			name: "Outermost match first",
+			pred: func(_ string, value any) bool {
+				_, ok := value.(int)
+				return ok
+			},
+			wantKey:   "count",
+			wantValue: 3,
+			wantFound: true,
+		},
+		{
+			name: "No match",
+			pred: func(key string, _ any) bool {
+				return key == "missing"
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, value, found := err.FindArg(test.pred)
+			if test.wantKey != key || test.wantValue != value || test.wantFound != found {
+				t.Errorf("Result not equal\n\t\twant=%s, %v, %t\n\t\t got=%s, %v, %t",
+					test.wantKey, test.wantValue, test.wantFound, key, value, found)
+			}
+		})
+	}
+}
+
+func TestRenderWith(t *testing.T) {
+	err := serr.Wrap(
+		serr.New("not found").Args("id", 1),
+		"lookup failed", "table", "users",
+	).WithLevel(slog.LevelWarn)
+
+	t.Run("JSON handler", func(t *testing.T) {
+		got, e := serr.RenderWith(err, func(w io.Writer) slog.Handler {
+			return slog.NewJSONHandler(w, nil)
+		})
+		if e != nil {
+			t.Fatalf("Unexpected error: %v", e)
+		}
+		want := `{"level":"WARN","msg":"lookup failed","table":"users","id":1}`
+		if want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+	t.Run("Text handler", func(t *testing.T) {
+		got, e := serr.RenderWith(err, func(w io.Writer) slog.Handler {
+			return slog.NewTextHandler(w, nil)
+		})
+		if e != nil {
+			t.Fatalf("Unexpected error: %v", e)
+		}
+		want := `level=WARN msg="lookup failed" table=users id=1`
+		if want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+	t.Run("Standard error", func(t *testing.T) {
+		got, e := serr.RenderWith(errors.New("disk full"), func(w io.Writer) slog.Handler {
+			return slog.NewTextHandler(w, nil)
+		})
+		if e != nil {
+			t.Fatalf("Unexpected error: %v", e)
+		}
+		want := `level=ERROR msg="disk full"`
+		if want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+	})
+}
+
+func TestMetricLabels(t *testing.T) {
+	defer func() { serr.MetricSafeKeys = nil }()
+	serr.MetricSafeKeys = []string{"region"}
+
+	inner := serr.New("query failed").
+		MetricArgs("status", 503).
+		Args("user_id", "u-8ce1", "region", "us-east")
+	err := serr.Wrap(inner, "load failed", "request_id", "r-42").
+		MetricArgs("status", 500, "kind", "load")
+
+	want := map[string]string{"status": "500", "kind": "load", "region": "us-east"}
+	if got := err.MetricLabels(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+	wantMsg := "load failed [request_id='r-42'] [status=500] [kind='load']"
+	if got := err.Error(); wantMsg != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", wantMsg, got)
+	}
+	if got := serr.New("failed").Args("user_id", "u-1").MetricLabels(); got != nil {
+		t.Errorf("MetricLabels() without safe args should be nil; got %v", got)
+	}
+	errSentinel := serr.New("failed")
+	errSentinel.MetricArgs("region", "us")
+	want = map[string]string{"region": "eu"}
+	if got := errSentinel.MetricArgs("region", "eu").MetricLabels(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+	if got := errSentinel.Error(); got != "failed" {
+		t.Errorf("MetricArgs() changed the sentinel; got %s", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	notFound := func(id int, table string) serr.SError {
+		return serr.Wrap(serr.New("not found").Args("id", id), "lookup failed", "table", table)
+	}
+	same1 := notFound(1, "users").Fingerprint()
+	same2 := notFound(2, "orders").Fingerprint()
+	if same1 != same2 {
+		t.Errorf("Errors of the same kind should share a fingerprint\n\t\twant=%s\n\t\t got=%s", same1, same2)
+	}
+	var kinds = []struct {
+		name string
+		err  serr.SError
+	}{
+		{name: "Different message", err: serr.Wrap(serr.New("gone").Args("id", 1), "lookup failed", "table", "users")},
+		{name: "Different keys", err: serr.Wrap(serr.New("not found").Args("key", 1), "lookup failed", "table", "users")},
+		{name: "Missing layer", err: serr.New("not found").Args("id", 1, "table", "users")},
+	}
+	for _, kind := range kinds {
+		t.Run(kind.name, func(t *testing.T) {
+			if got := kind.err.Fingerprint(); got == same1 {
+				t.Errorf("Errors of different kinds should not share fingerprint %s", got)
+			}
+		})
+	}
+	t.Run("With values", func(t *testing.T) {
+		defer func() { serr.FingerprintValues = false }()
+		serr.FingerprintValues = true
+		if notFound(1, "users").Fingerprint() == notFound(2, "users").Fingerprint() {
+			t.Errorf("Fingerprints should differ by value when FingerprintValues is true")
+		}
+		if notFound(1, "users").Fingerprint() != notFound(1, "users").Fingerprint() {
+			t.Errorf("Fingerprints of equal errors should match when FingerprintValues is true")
+		}
+	})
+}
+
+func TestRenderTruncationMarker(t *testing.T) {
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	long := strings.Repeat("x", 40)
+	var tests = []struct {
+		name   string
+		config func(rc *serr.RenderConfig)
+		err    serr.SError
+		want   string
+	}{
+		{
+			name:   "MaxValueLen with ellipsis",
+			config: func(rc *serr.RenderConfig) { rc.MaxValueLen = 15 },
+			err:    serr.New("failed").Args("sql", "SELECT * FROM users WHERE id=1"),
+			want:   fmt.Sprintf("failed [sql='SELECT %sRE id=1']", serr.EllipsisRune),
+		},
+		{
+			name: "MaxValueLen with marker",
+			config: func(rc *serr.RenderConfig) {
+				rc.MaxValueLen = 15
+				rc.TruncationMarker = serr.TruncatedMarker
+			},
+			err:  serr.New("failed").Args("sql", "SELECT * FROM users WHERE id=1"),
+			want: "failed [sql='SELE[truncated]']",
+		},
+		{
+			name: "MaxErrorLen with marker",
+			config: func(rc *serr.RenderConfig) {
+				rc.MaxErrorLen = 20
+				rc.TruncationMarker = serr.TruncatedMarker
+			},
+			err:  serr.New("failed").Args("data", long),
+			want: "failed [d[truncated]",
+		},
+		{
+			name: "Marker longer than cap",
+			config: func(rc *serr.RenderConfig) {
+				rc.MaxErrorLen = 5
+				rc.TruncationMarker = serr.TruncatedMarker
+			},
+			err:  serr.New("failed").Args("data", long),
+			want: "[trun",
+		},
+		{
+			name: "Short error untouched",
+			config: func(rc *serr.RenderConfig) {
+				rc.MaxErrorLen = 20
+				rc.TruncationMarker = serr.TruncatedMarker
+			},
+			err:  serr.New("failed"),
+			want: "failed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serr.Render = serr.DefaultRenderConfig
+			test.config(&serr.Render)
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+	if got := serr.Excerpt(long, 9); strings.Contains(got, serr.TruncatedMarker) {
+		t.Errorf("Excerpt() should not use the truncation marker; got %s", got)
+	}
+}
+
+func TestChainThroughNonSError(t *testing.T) {
+	inner := serr.New("query failed").
+		WithRequestID("r-42").
+		WithLevel(slog.LevelWarn).
+		Tag("db", "postgres").
+		Logged()
+	outer := serr.Wrap(fmt.Errorf("loading user: %w", inner), "handler failed", "route", "/users")
+
+	if id, found := outer.RequestID(); !found || id != "r-42" {
+		t.Errorf("RequestID() not found through fmt.Errorf() link; got %q, %t", id, found)
+	}
+	if got := outer.Level(); got != slog.LevelWarn {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", slog.LevelWarn, got)
+	}
+	if got := outer.Tags()["db"]; got != "postgres" {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", "postgres", got)
+	}
+	if !outer.IsLogged() {
+		t.Errorf("IsLogged() should be true through fmt.Errorf() link")
+	}
+	var msgs []string
+	outer.Walk(func(sErr serr.SError) bool {
+		msgs = append(msgs, sErr.BaseMessage())
+		return true
+	})
+	want := []string{"handler failed", "query failed"}
+	if !slices.Equal(want, msgs) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, msgs)
+	}
+	wantKeys := []string{"request_id", "route"}
+	if got := outer.AllArgKeys(); !slices.Equal(wantKeys, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", wantKeys, got)
+	}
+}
+
+func TestNewWithAttrs(t *testing.T) {
+	var tests = []struct {
+		name  string
+		attrs []any
+		want  []any
+	}{
+		{name: "None", attrs: nil, want: nil},
+		{name: "Lone attr", attrs: []any{slog.Int("id", 1)}, want: []any{"id", int64(1)}},
+		{name: "Key-value pairs", attrs: []any{"id", 1, "table", "users"}, want: []any{"id", 1, "table", "users"}},
+		{
+			name:  "Mixed",
+			attrs: []any{"id", 1, slog.String("table", "users"), "ok", true},
+			want:  []any{"id", 1, "table", "users", "ok", true},
+		},
+		{name: "Trailing key", attrs: []any{"id", 1, "table"}, want: []any{"id", 1, serr.BadKey, "table"}},
+		{name: "Value without key", attrs: []any{42, "id", 1}, want: []any{serr.BadKey, 42, "id", 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := serr.NewWithAttrs("failed", test.attrs...)
+			if got := err.GetArgs(); !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRangeArgs(t *testing.T) {
+	err := serr.New("failed").Args("a", 1, "b", "two", "c", 3.0)
+
+	var got []any
+	err.RangeArgs(func(key string, value any) bool {
+		got = append(got, key, value)
+		return true
+	})
+	want := []any{"a", 1, "b", "two", "c", 3.0}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got)
+	}
+
+	var keys []string
+	err.RangeArgs(func(key string, _ any) bool {
+		keys = append(keys, key)
+		return key != "b"
+	})
+	wantKeys := []string{"a", "b"}
+	if !slices.Equal(wantKeys, keys) {
+		t.Errorf("RangeArgs() did not stop when fn returned false\n\t\twant=%v\n\t\t got=%v", wantKeys, keys)
+	}
+
+	called := false
+	serr.New("failed").RangeArgs(func(string, any) bool {
+		called = true
+		return true
+	})
+	if called {
+		t.Errorf("RangeArgs() should not call fn for an error without args")
+	}
+}
+
+func TestLongMessage(t *testing.T) {
+	inner := serr.New("connection refused").
+		LongMessage("The database did not accept the connection; check that it is running.")
+	err := serr.Wrap(inner, "load failed", "user", "alice").
+		LongMessage("User data could not be loaded, so the request was aborted.")
+
+	want := "load failed [user='alice']"
+	if got := err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	want = "load failed [user='alice']\n" +
+		"User data could not be loaded, so the request was aborted.\n" +
+		"The database did not accept the connection; check that it is running."
+	if got := err.PrettyVerbose(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	want = "failed"
+	if got := serr.New("failed").PrettyVerbose(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+}
+
+func TestRenderArgs(t *testing.T) {
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	args := []any{"id", 1, "table", "users"}
+	want := "[id=1] [table='users']"
+	if got := serr.RenderArgs(args); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if want, got := "failed "+want, serr.New("failed").Args(args...).Error(); want != got {
+		t.Errorf("RenderArgs() should match Error()\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := serr.RenderArgs(nil); got != "" {
+		t.Errorf("RenderArgs(nil) should be empty; got %s", got)
+	}
+
+	m := map[string]any{"table": "users", "id": 1}
+	if got := serr.RenderArgsMap(m); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+
+	serr.Render.ArgsFormat = serr.JSONArgs
+	want = `{"id":1,"table":"users"}`
+	if got := serr.RenderArgs(args); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+}
+
+func TestWrapStd(t *testing.T) {
+	_, cause := os.Open("/does/not/exist")
+	err := serr.WrapStd(cause, "loading config", "attempt", 2)
+
+	if got := errors.Unwrap(err); got != cause {
+		t.Errorf("errors.Unwrap() should return the cause directly\n\t\twant=%v\n\t\t got=%v", cause, got)
+	}
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "/does/not/exist" {
+		t.Errorf("errors.As() did not extract the *fs.PathError; got %v", pathErr)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("errors.Is() did not find fs.ErrNotExist")
+	}
+	want := "loading config [attempt=2]"
+	if got := err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	// The SError, the *fs.PathError and the syscall.Errno it wraps.
+	if got := err.ChainDepth(); got != 3 {
+		t.Errorf("Result not equal\n\t\twant=%d\n\t\t got=%d", 3, got)
+	}
+	if err := serr.WrapStd(nil, "loading config"); err != nil {
+		t.Errorf("WrapStd(nil) should be nil; got %v", err)
+	}
+}
+
+func TestSameRoot(t *testing.T) {
+	sentinel := serr.New("not found")
+	var tests = []struct {
+		name string
+		a    error
+		b    error
+		want bool
+	}{
+		{
+			name: "Same SError sentinel wrapped differently",
+			a:    serr.Wrap(sentinel.Args("id", 1), "lookup failed"),
+			b:    fmt.Errorf("handler: %w", serr.Wrap(sentinel.Args("id", 2), "load failed", "table", "users")),
+			want: true,
+		},
+		{
+			name: "Same standard sentinel wrapped differently",
+			a:    serr.Wrap(fs.ErrNotExist, "reading config"),
+			b:    fmt.Errorf("opening cache: %w", fs.ErrNotExist),
+			want: true,
+		},
+		{
+			name: "Different roots",
+			a:    serr.Wrap(fs.ErrNotExist, "reading config"),
+			b:    serr.Wrap(fs.ErrPermission, "reading config"),
+			want: false,
+		},
+		{
+			name: "Same message, different SErrors",
+			a:    serr.Wrap(serr.New("not found"), "lookup failed"),
+			b:    serr.Wrap(sentinel, "lookup failed"),
+			want: false,
+		},
+		{
+			name: "Nil",
+			a:    nil,
+			b:    fs.ErrNotExist,
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serr.SameRoot(test.a, test.b); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%t\n\t\t got=%t", test.want, got)
+			}
+		})
+	}
+	if got := serr.Root(serr.Wrap(fs.ErrNotExist, "reading")); got != fs.ErrNotExist {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", fs.ErrNotExist, got)
+	}
+}
+
+func TestWrapOnce(t *testing.T) {
+	cause := errors.New("timeout")
+
+	first := serr.WrapOnce(cause, "sync", "syncing account")
+	want := "syncing account"
+	if got := first.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := first.ChainDepth(); got != 2 {
+		t.Errorf("First WrapOnce() should add a layer\n\t\twant=%d\n\t\t got=%d", 2, got)
+	}
+
+	again := serr.WrapOnce(first, "sync", "syncing account")
+	if again != first {
+		t.Errorf("Second WrapOnce() should return the error unchanged; got %v", again)
+	}
+
+	retried := serr.WrapOnce(fmt.Errorf("retry 2: %w", first), "sync", "syncing account")
+	if got := retried.ChainDepth(); got != 3 {
+		t.Errorf("WrapOnce() through a non-SError should not add a layer\n\t\twant=%d\n\t\t got=%d", 3, got)
+	}
+	if !errors.Is(retried, cause) {
+		t.Errorf("errors.Is() should still find the cause")
+	}
+
+	withArg := serr.Wrap(cause, "loading", "sync", 1)
+	if got := serr.WrapOnce(withArg, "sync", "syncing account"); got.ChainDepth() != withArg.ChainDepth() {
+		t.Errorf("WrapOnce() should not wrap an error with an arg of the same key")
+	}
+
+	other := serr.WrapOnce(first, "load", "loading account")
+	if got := other.ChainDepth(); got != 3 {
+		t.Errorf("WrapOnce() with a new key should add a layer\n\t\twant=%d\n\t\t got=%d", 3, got)
+	}
+	if serr.WrapOnce(nil, "sync", "syncing account") != nil {
+		t.Errorf("WrapOnce(nil) should be nil")
+	}
+}
+
+func TestSErrorValuedArgs(t *testing.T) {
+	cause := serr.Join(serr.New("not found").Args("id", 1), errors.New("cache miss"))
+	err := serr.New("lookup failed").Args("cause", serr.New("not found").Args("id", 1), "table", "users")
+
+	want := "lookup failed [cause=not found [id=1]] [table='users']"
+	if got := err.Error(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	want = "lookup failed [cause=not found [id=1]; cache miss]"
+	if got := serr.New("lookup failed").Args("cause", cause).Error(); want != got {
+		t.Errorf("Multi-line message not compacted\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+
+	attr, _ := err.Attr("cause")
+	if attr.Value.Kind() != slog.KindGroup {
+		t.Fatalf("SError-valued arg should be a group; got %s", attr.Value.Kind())
+	}
+	got, e := serr.RenderWith(err, func(w io.Writer) slog.Handler {
+		return slog.NewJSONHandler(w, nil)
+	})
+	if e != nil {
+		t.Fatalf("Unexpected error: %v", e)
+	}
+	want = `{"level":"ERROR","msg":"lookup failed","cause":{"msg":"not found","id":1},"table":"users"}`
+	if want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	defer serr.SetDefaults()
+	sentinel := serr.NewSentinel("not found", "id")
+	serr.SetDefaults("service", "api", "version", "1.2.3")
+
+	var tests = []struct {
+		name string
+		err  serr.SError
+		want string
+	}{
+		{
+			name: "New",
+			err:  serr.New("failed"),
+			want: "failed [service='api'] [version='1.2.3']",
+		},
+		{
+			name: "New with args",
+			err:  serr.New("failed").Args("id", 1),
+			want: "failed [id=1] [service='api'] [version='1.2.3']",
+		},
+		{
+			name: "Overridden",
+			err:  serr.New("failed").Args("version", "2.0.0"),
+			want: "failed [version='2.0.0'] [service='api']",
+		},
+		{
+			name: "Wrap",
+			err:  serr.Wrap(errors.New("timeout"), "fetch failed", "url", "http://x"),
+			want: "fetch failed [url='http://x'] [service='api'] [version='1.2.3']",
+		},
+		{
+			name: "Cast",
+			err:  serr.Cast(errors.New("timeout")),
+			want: "timeout [service='api'] [version='1.2.3']",
+		},
+		{
+			name: "WrapStd",
+			err:  serr.WrapStd(errors.New("timeout"), "fetch failed", "url", "http://x"),
+			want: "fetch failed [url='http://x'] [service='api'] [version='1.2.3']",
+		},
+		{
+			name: "Enrich",
+			err:  serr.Enrich(errors.New("timeout"), "url", "http://x"),
+			want: "timeout [url='http://x'] [service='api'] [version='1.2.3']",
+		},
+		{
+			name: "MetricArgs",
+			err:  serr.New("failed").Args("id", 1).MetricArgs("status", 503),
+			want: "failed [id=1] [status=503] [service='api'] [version='1.2.3']",
+		},
+		{
+			name: "Sentinel declared before SetDefaults",
+			err:  sentinel.Args("id", 1),
+			want: "not found [id=1] [service='api'] [version='1.2.3']",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+		})
+	}
+	if err := serr.ValidateArgs(serr.NewSentinel("not found", "id").Args("id", 1)); err != nil {
+		t.Errorf("ValidateArgs() should not check default args; got %v", err)
+	}
+
+	wrapped := serr.Wrap(serr.New("inner"), "outer")
+	want := "outer [service='api'] [version='1.2.3']: inner"
+	if got := wrapped.ChainError(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := wrapped.TotalArgCount(); got != 2 {
+		t.Errorf("TotalArgCount() should count the defaults once; got %d", got)
+	}
+	if got := len(wrapped.AllAttrs()); got != 2 {
+		t.Errorf("AllAttrs() should include the defaults once; got %d attrs", got)
+	}
+	b, err := json.Marshal(wrapped)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	wantJSON := `{"message":"outer","args":{"service":"api","version":"1.2.3"},"wrapped":{"message":"inner"}}`
+	if got := string(b); wantJSON != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", wantJSON, got)
+	}
+
+	serr.SetDefaults()
+	if got := serr.New("failed").Error(); got != "failed" {
+		t.Errorf("SetDefaults() with no args should clear the defaults; got %s", got)
+	}
+}
+
+func TestRequireValidArgs(t *testing.T) {
+	defer func() { serr.RequireValidArgs = false }()
+	template := serr.NewSentinel("not found", "id", "table")
+	var tests = []struct {
+		name      string
+		strict    bool
+		err       serr.SError
+		want      string
+		wantAttrs int
+	}{
+		{
+			name:      "Lenient, missing field",
+			err:       template.Clone().Args("id", 1),
+			want:      "not found [id=1]",
+			wantAttrs: 1,
+		},
+		{
+			name:      "Strict, missing field",
+			strict:    true,
+			err:       template.Clone().Args("id", 1),
+			want:      "not found [id=1] (missing required field: table)",
+			wantAttrs: 2,
+		},
+		{
+			name:      "Strict, missing all fields",
+			strict:    true,
+			err:       template.Clone(),
+			want:      "not found (missing required field: id, table)",
+			wantAttrs: 1,
+		},
+		{
+			name:      "Strict, all fields present",
+			strict:    true,
+			err:       template.Clone().Args("id", 1, "table", "users"),
+			want:      "not found [id=1] [table='users']",
+			wantAttrs: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serr.RequireValidArgs = test.strict
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", test.want, got)
+			}
+			attrs := test.err.Attrs()
+			if len(attrs) != test.wantAttrs {
+				t.Errorf("Attrs() length mismatch\n\t\twant=%d\n\t\t got=%d", test.wantAttrs, len(attrs))
+			}
+			_, missing := test.err.Attr(serr.MissingKey)
+			wantMissing := test.strict && strings.Contains(test.want, "missing")
+			if missing != wantMissing {
+				t.Errorf("Attr(MissingKey) presence mismatch\n\t\twant=%t\n\t\t got=%t", wantMissing, missing)
+			}
+		})
+	}
+}
+
+func TestAsRecord(t *testing.T) {
+	err := serr.Wrap(serr.New("not found").Args("id", 1), "lookup failed", "table", "users")
+
+	record := serr.AsRecord(err, slog.LevelWarn, "request failed")
+	if record.Level != slog.LevelWarn || record.Message != "request failed" {
+		t.Errorf("Result not equal\n\t\twant=%s %s\n\t\t got=%s %s", slog.LevelWarn, "request failed", record.Level, record.Message)
+	}
+	if record.Time.IsZero() {
+		t.Errorf("AsRecord() should set the time")
+	}
+	var got []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		got = append(got, attr)
+		return true
+	})
+	want := err.AllAttrs()
+	if len(want) != len(got) {
+		t.Fatalf("Attrs length mismatch\n\t\twant=%d\n\t\t got=%d", len(want), len(got))
+	}
+	for i := range want {
+		if !want[i].Equal(got[i]) {
+			t.Errorf("Attr not equal\n\t\twant=%s\n\t\t got=%s", want[i], got[i])
+		}
+	}
+
+	if record := serr.AsRecord(err, slog.LevelError, ""); record.Message != err.Error() {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", err.Error(), record.Message)
+	}
+	if record := serr.AsRecord(nil, slog.LevelInfo, "ok"); record.Message != "ok" || record.NumAttrs() != 0 {
+		t.Errorf("AsRecord(nil) should have the message and no attrs; got %q with %d attrs",
+			record.Message, record.NumAttrs())
+	}
+}
+
+func TestEqualFunc(t *testing.T) {
+	withinSecond := func(key string, va, vb any) bool {
+		if key != "elapsed" {
+			return reflect.DeepEqual(va, vb)
+		}
+		fa, okA := va.(float64)
+		fb, okB := vb.(float64)
+		return okA && okB && fa-fb < 1 && fb-fa < 1
+	}
+	timeout := func(elapsed float64, host string) serr.SError {
+		return serr.Wrap(serr.New("timeout").Args("elapsed", elapsed), "fetch failed", "host", host)
+	}
+	var tests = []struct {
+		name      string
+		a         serr.SError
+		b         serr.SError
+		want      bool
+		wantExact bool
+	}{
+		{name: "Identical", a: timeout(2.5, "x"), b: timeout(2.5, "x"), want: true, wantExact: true},
+		{name: "Within tolerance", a: timeout(2.5, "x"), b: timeout(2.9, "x"), want: true},
+		{name: "Outside tolerance", a: timeout(2.5, "x"), b: timeout(4.0, "x")},
+		{name: "Other value differs", a: timeout(2.5, "x"), b: timeout(2.5, "y")},
+		{name: "Different message", a: timeout(2.5, "x"), b: serr.Wrap(serr.New("timeout").Args("elapsed", 2.5), "load failed", "host", "x")},
+		{name: "Different keys", a: timeout(2.5, "x"), b: serr.Wrap(serr.New("timeout").Args("elapsed", 2.5), "fetch failed", "url", "x")},
+		{name: "Different depth", a: timeout(2.5, "x"), b: serr.New("fetch failed").Args("host", "x")},
+		{name: "Both nil", a: nil, b: nil, want: true, wantExact: true},
+		{name: "One nil", a: timeout(2.5, "x"), b: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := serr.EqualFunc(test.a, test.b, withinSecond); test.want != got {
+				t.Errorf("Result not equal\n\t\twant=%t\n\t\t got=%t", test.want, got)
+			}
+			if got := serr.Equal(test.a, test.b); test.wantExact != got {
+				t.Errorf("Equal() not equal\n\t\twant=%t\n\t\t got=%t", test.wantExact, got)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	defer func(keys []string, width int) {
+		serr.SummaryKeys, serr.SummaryWidth = keys, width
+	}(serr.SummaryKeys, serr.SummaryWidth)
+	serr.SummaryKeys = []string{"service", "code"}
+	serr.SummaryWidth = 40
+
+	inner := serr.New("connection refused").Args("host", "db-1", "code", 111)
+	err := serr.Wrap(inner, "payment\nfailed", "service", "billing", "user", "alice")
+
+	want := "payment failed [service='billing'] [code=111]"
+	want = serr.Excerpt(want, 40)
+	got := err.Summary()
+	if want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if n := utf8.RuneCountInString(got); n > serr.SummaryWidth {
+		t.Errorf("Summary() exceeds SummaryWidth: %d > %d", n, serr.SummaryWidth)
+	}
+	for _, key := range []string{"user", "host"} {
+		if strings.Contains(got, key) {
+			t.Errorf("Summary() should not include %q; got %s", key, got)
+		}
+	}
+
+	serr.SummaryKeys = nil
+	if want, got := "payment failed", err.Summary(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+}
+
+func TestSafeArgs(t *testing.T) {
+	var buf strings.Builder
+	defer func(logger *slog.Logger) { slog.SetDefault(logger) }(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	err := serr.New("failed").SafeArgs()
+	var got serr.SError
+	if didPanic(func() { got = err.Args("id", 1, "orphan") }) {
+		t.Fatalf("Args() with odd args should not panic after SafeArgs()")
+	}
+	want := []any{"id", 1}
+	if !reflect.DeepEqual(want, got.GetArgs()) {
+		t.Errorf("Result not equal\n\t\twant=%v\n\t\t got=%v", want, got.GetArgs())
+	}
+	if didPanic(func() { got.Args("again") }) {
+		t.Errorf("SafeArgs() should carry over to clones")
+	}
+	if n := strings.Count(buf.String(), "dropping trailing arg"); n != 1 {
+		t.Errorf("Dropped args should be logged once\n\t\twant=%d\n\t\t got=%d", 1, n)
+	}
+	if !didPanic(func() { serr.New("failed").Args("orphan") }) {
+		t.Errorf("Args() with odd args should still panic without SafeArgs()")
+	}
+	var attrs []slog.Attr
+	if didPanic(func() { attrs = serr.New("lib").SafeArgs().Args(1, 2).Attrs() }) {
+		t.Fatalf("Attrs() with a non-string key should not panic after SafeArgs()")
+	}
+	if len(attrs) != 1 || attrs[0].Key != "1" {
+		t.Errorf("Attrs() should format a non-string key using %%v; got %v", attrs)
+	}
+}
+
+func TestCleanMessage(t *testing.T) {
+	defer func() { serr.Render = serr.DefaultRenderConfig }()
+	inner := serr.New("not found").Args("id", 1)
+	err := serr.Wrap(inner, "lookup failed", "table", "users").
+		Op("LoadUser").
+		WithHelpURL("https://example.com/errors/lookup")
+
+	want := "LoadUser: lookup failed [table='users']"
+	if got := err.CleanMessage(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := err.Error(); want != got {
+		t.Errorf("Error() should match CleanMessage() by default\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+
+	serr.Render.ShowHelpURL = true
+	if got := err.CleanMessage(); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	wantErr := want + " (see: https://example.com/errors/lookup)"
+	if got := err.Error(); wantErr != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", wantErr, got)
+	}
+
+	serr.Render = serr.DefaultRenderConfig
+	serr.Render.MaxErrorLen = 12
+	if got := err.CleanMessage(); want != got {
+		t.Errorf("CleanMessage() should ignore MaxErrorLen\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+	if got := err.Error(); utf8.RuneCountInString(got) > 12 {
+		t.Errorf("Error() should apply MaxErrorLen; got %s", got)
+	}
+}
+
+type jsonPoint struct{ X, Y int }
+
+func (p jsonPoint) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("[%d,%d]", p.X, p.Y)), nil
+}
+
+func TestMarshalJSON(t *testing.T) {
+	inner := serr.New("timeout").Args("retries", 3)
+	err := serr.Wrap(inner, "lookup failed",
+		"id", 42,
+		"ratio", 0.5,
+		"found", false,
+		"name", "users",
+		"at", jsonPoint{X: 1, Y: 2},
+	).WithCode("E_LOOKUP")
+
+	b, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatalf("json.Marshal() failed: %v", jsonErr)
+	}
+	want := `{"message":"lookup failed","code":"E_LOOKUP",` +
+		`"args":{"at":[1,2],"found":false,"id":42,"name":"users","ratio":0.5},` +
+		`"wrapped":{"message":"timeout","args":{"retries":3}}}`
+	if got := string(b); want != got {
+		t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+	}
+
+	var decoded struct {
+		Args map[string]any `json:"args"`
+	}
+	if jsonErr = json.Unmarshal(b, &decoded); jsonErr != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", jsonErr)
+	}
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{key: "id", want: float64(42)},
+		{key: "ratio", want: 0.5},
+		{key: "found", want: false},
+		{key: "name", want: "users"},
+		{key: "at", want: []any{float64(1), float64(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := decoded.Args[tt.key]; !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("Result not equal\n\t\twant=%#v\n\t\t got=%#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSettersLeaveSentinelUnchanged(t *testing.T) {
+	describe := func(err serr.SError) string {
+		_, hasURL := err.HelpURL()
+		_, hasRetryAfter := err.RetryAfter()
+		return fmt.Sprintf("%s|%s|%s|%q|%t|%v|%t|%t|%s|%d|%d|%t",
+			err.Args("k", "v").Error(), err.PrettyVerbose(), err.Code(), err.Ops(), hasURL, err.Tags(),
+			err.Retryable(), hasRetryAfter, err.Level(), serr.HTTPStatus(err),
+			len(err.Related()), didPanic(func() { err.Args("orphan") }))
+	}
+	want := describe(serr.New("failed"))
+	var tests = []struct {
+		name string
+		set  func(serr.SError) serr.SError
+	}{
+		{name: "Op", set: func(e serr.SError) serr.SError { return e.Op("A") }},
+		{name: "WithCode", set: func(e serr.SError) serr.SError { return e.WithCode("E") }},
+		{name: "WithHelpURL", set: func(e serr.SError) serr.SError { return e.WithHelpURL("https://x") }},
+		{name: "WithPrefix", set: func(e serr.SError) serr.SError { return e.WithPrefix("p") }},
+		{name: "HideArgsInError", set: func(e serr.SError) serr.SError { return e.HideArgsInError() }},
+		{name: "SafeArgs", set: func(e serr.SError) serr.SError { return e.SafeArgs() }},
+		{name: "LongMessage", set: func(e serr.SError) serr.SError { return e.LongMessage("more") }},
+		{name: "Tag", set: func(e serr.SError) serr.SError { return e.Tag("kind", "io") }},
+		{name: "WithRelated", set: func(e serr.SError) serr.SError { return e.WithRelated("cause", io.EOF) }},
+		{name: "WithRetryable", set: func(e serr.SError) serr.SError { return e.WithRetryable(true) }},
+		{name: "WithRetryAfter", set: func(e serr.SError) serr.SError { return e.WithRetryAfter(time.Second) }},
+		{name: "WithLevel", set: func(e serr.SError) serr.SError { return e.WithLevel(slog.LevelWarn) }},
+		{name: "WithHTTPStatus", set: func(e serr.SError) serr.SError { return e.WithHTTPStatus(404) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errSentinel := serr.New("failed")
+			if got := describe(test.set(errSentinel)); want == got {
+				t.Errorf("%s() had no effect on the error it returned", test.name)
+			}
+			if got := describe(errSentinel); want != got {
+				t.Errorf("Sentinel changed\n\t\twant=%s\n\t\t got=%s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package serrhttp attaches details of HTTP requests to serr errors, kept apart
+// from serr so that programs importing serr do not link in net/http.
+package serrhttp
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+const (
+	MethodKey  = "http_method"
+	PathKey    = "http_path"
+	HeadersKey = "http_headers"
+)
+
+// SensitiveHeaders lists the canonical names of request headers that
+// WithRequest() never attaches.
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// WithRequest returns err enriched as serr.Enrich() does with the method and
+// URL path of r, along with its headers other than SensitiveHeaders as a map of
+// header name to comma-joined values. The path is omitted if r has no URL, and
+// err is enriched with nothing if r is nil. It returns nil if err is nil.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithRequest(err error, r *http.Request) serr.SError {
+	var args []any
+	var headers map[string]string
+	if r == nil {
+		goto end
+	}
+	args = append(args, MethodKey, r.Method)
+	if r.URL != nil {
+		args = append(args, PathKey, r.URL.Path)
+	}
+	headers = make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		name = http.CanonicalHeaderKey(name)
+		if slices.Contains(SensitiveHeaders, name) {
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	args = append(args, HeadersKey, headers)
+end:
+	return serr.Enrich(err, args...)
+}
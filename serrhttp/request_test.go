@@ -0,0 +1,77 @@
+package serrhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+	"github.com/mikeschinkel/go-serr/serrhttp"
+)
+
+func TestWithRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users/42?expand=true", nil)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Add("Accept", "text/html")
+	r.Header.Add("Accept", "application/json")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Cookie", "session=secret")
+	noURL := &http.Request{Method: "GET"}
+
+	var tests = []struct {
+		name string
+		r    *http.Request
+		want map[string]any
+	}{
+		{
+			name: "Request",
+			r:    r,
+			want: map[string]any{
+				"id":               int64(42),
+				serrhttp.MethodKey: "POST",
+				serrhttp.PathKey:   "/users/42",
+				serrhttp.HeadersKey: map[string]string{
+					"Content-Type": "application/json",
+					"Accept":       "text/html, application/json",
+				},
+			},
+		},
+		{
+			name: "No URL",
+			r:    noURL,
+			want: map[string]any{
+				"id":                int64(42),
+				serrhttp.MethodKey:  "GET",
+				serrhttp.HeadersKey: map[string]string{},
+			},
+		},
+		{
+			name: "Nil request",
+			want: map[string]any{"id": int64(42)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := serrhttp.WithRequest(serr.New("update failed").Args("id", 42), test.r)
+			got := make(map[string]any)
+			for _, attr := range err.AllAttrs() {
+				got[attr.Key] = attr.Value.Any()
+			}
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Args mismatch\n\t\twant=%v\n\t\t got=%v", test.want, got)
+			}
+		})
+	}
+	t.Run("Strict sentinel", func(t *testing.T) {
+		err := serrhttp.WithRequest(serr.NewSentinel("update failed", "id").Args("id", 42), r)
+		if _, found := err.Attr(serrhttp.MethodKey); !found {
+			t.Errorf("WithRequest() should attach %s to a strict sentinel; got %v", serrhttp.MethodKey, err)
+		}
+	})
+	t.Run("Nil error", func(t *testing.T) {
+		if err := serrhttp.WithRequest(nil, r); err != nil {
+			t.Errorf("WithRequest(nil) should be nil; got %v", err)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+// Package serrtest provides test helpers for code that uses serr, kept apart
+// from serr so that programs importing serr do not link in package testing.
+package serrtest
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+// AssertEqual reports a test error via t.Errorf() if got does not equal want.
+// The report shows only the differing regions of each, as found by serr.Diff()
+// with excerpts of at most n runes, along with the rune offset of the first
+// difference.
+//
+//goland:noinspection GoUnusedExportedFunction
+func AssertEqual(t testing.TB, got, want string, n int) {
+	t.Helper()
+	if got == want {
+		return
+	}
+	wantDiff, gotDiff, start, _ := serr.Diff(want, got, n)
+	t.Errorf("Strings differ at rune %d\n\twant=%s\n\t got=%s",
+		start,
+		wantDiff,
+		gotDiff,
+	)
+}
@@ -0,0 +1,67 @@
+package serrtest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+	"github.com/mikeschinkel/go-serr/serrtest"
+)
+
+var Xs = strings.Repeat("-", 256)
+
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertEqual(t *testing.T) {
+	var tests = []struct {
+		name      string
+		got, want string
+		wantError string
+	}{
+		{
+			name: "Equal",
+			got:  "same",
+			want: "same",
+		},
+		{
+			name:      "Differ in middle",
+			got:       Xs[:50] + "XYZ" + Xs[:50],
+			want:      Xs[:50] + "ABC" + Xs[:50],
+			wantError: "Strings differ at rune 50\n\twant=ABC\n\t got=XYZ",
+		},
+		{
+			name:      "Long difference excerpted",
+			got:       "QRSTUVXYZ" + Xs[:50],
+			want:      "ABCDEFGHI" + Xs[:50],
+			wantError: fmt.Sprintf("Strings differ at rune 0\n\twant=AB%sHI\n\t got=QR%sYZ", serr.EllipsisRune, serr.EllipsisRune),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			serrtest.AssertEqual(tb, test.got, test.want, 5)
+			if test.wantError == "" {
+				if len(tb.errors) != 0 {
+					t.Errorf("AssertEqual() should be silent on match; got %q", tb.errors)
+				}
+				return
+			}
+			if len(tb.errors) != 1 {
+				t.Fatalf("AssertEqual() should report once on mismatch; got %q", tb.errors)
+			}
+			if tb.errors[0] != test.wantError {
+				t.Errorf("Report mismatch\n\t\twant=%q\n\t\t got=%q", test.wantError, tb.errors[0])
+			}
+		})
+	}
+}
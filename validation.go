@@ -0,0 +1,83 @@
+package serr
+
+import (
+	"strings"
+)
+
+const (
+	// ValidationMessage is the message Error() renders before the field errors
+	// of a ValidationError.
+	ValidationMessage = "validation failed"
+	// FieldKey is the key of the arg that carries the name of the field on each
+	// error a ValidationError unwraps to.
+	FieldKey = "field"
+)
+
+// ValidationError collects messages about invalid fields, e.g. of a form or
+// request, rendering them together while keeping each available by field.
+type ValidationError struct {
+	fields   []string
+	messages map[string]string
+}
+
+// NewValidation returns an empty ValidationError.
+//
+//goland:noinspection GoUnusedExportedFunction
+func NewValidation() *ValidationError {
+	return &ValidationError{
+		messages: make(map[string]string),
+	}
+}
+
+// AddFieldError records message as the error for field. Adding a second error
+// for the same field replaces its message but keeps the field's position.
+func (v *ValidationError) AddFieldError(field, message string) *ValidationError {
+	if _, ok := v.messages[field]; !ok {
+		v.fields = append(v.fields, field)
+	}
+	v.messages[field] = message
+	return v
+}
+
+// FieldErrors returns the message recorded for each field.
+func (v *ValidationError) FieldErrors() map[string]string {
+	messages := make(map[string]string, len(v.messages))
+	for field, message := range v.messages {
+		messages[field] = message
+	}
+	return messages
+}
+
+// Len returns the number of fields with errors.
+func (v *ValidationError) Len() int {
+	return len(v.fields)
+}
+
+// Err returns v, or nil if no field errors were added, so that callers can
+// return it directly.
+func (v *ValidationError) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Error renders the field errors in the order they were added, e.g.
+// "validation failed: email: is required; age: must be positive".
+func (v *ValidationError) Error() string {
+	parts := make([]string, len(v.fields))
+	for i, field := range v.fields {
+		parts[i] = field + ": " + v.messages[field]
+	}
+	return ValidationMessage + ": " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns an SError for each field error with the field's name attached
+// as an arg with the key FieldKey, so they are reachable by errors.As().
+func (v *ValidationError) Unwrap() []error {
+	errs := make([]error, len(v.fields))
+	for i, field := range v.fields {
+		errs[i] = New(v.messages[field]).Args(FieldKey, field)
+	}
+	return errs
+}
@@ -0,0 +1,49 @@
+package serr_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-serr"
+)
+
+func TestValidation(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		if err := serr.NewValidation().Err(); err != nil {
+			t.Errorf("Err() of an empty validation should be nil; got %v", err)
+		}
+	})
+
+	t.Run("Multiple fields", func(t *testing.T) {
+		v := serr.NewValidation().
+			AddFieldError("email", "is required").
+			AddFieldError("age", "must be positive").
+			AddFieldError("email", "is invalid")
+		err := v.Err()
+
+		want := "validation failed: email: is invalid; age: must be positive"
+		if got := err.Error(); want != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", want, got)
+		}
+
+		wantFields := map[string]string{"email": "is invalid", "age": "must be positive"}
+		if got := v.FieldErrors(); !reflect.DeepEqual(wantFields, got) {
+			t.Errorf("FieldErrors() mismatch\n\t\twant=%v\n\t\t got=%v", wantFields, got)
+		}
+
+		var ve *serr.ValidationError
+		if !errors.As(err, &ve) || ve.Len() != 2 {
+			t.Errorf("errors.As() did not find the ValidationError")
+		}
+
+		unwrapped := v.Unwrap()
+		if len(unwrapped) != 2 {
+			t.Fatalf("Unwrap() length mismatch\n\t\twant=%d\n\t\t got=%d", 2, len(unwrapped))
+		}
+		wantErr := "must be positive [field='age']"
+		if got := unwrapped[1].Error(); wantErr != got {
+			t.Errorf("Result not equal\n\t\twant=%s\n\t\t got=%s", wantErr, got)
+		}
+	})
+}